@@ -34,6 +34,10 @@ var defaultSettings = &Settings{
 	BatchIdleDuration:     &metav1.Duration{Duration: time.Second * 1},
 	TTLAfterNotRegistered: &metav1.Duration{Duration: time.Minute * 15},
 	DriftEnabled:          false,
+
+	CloudProviderRetries:    3,
+	CircuitBreakerThreshold: 5,
+	CircuitBreakerCooldown:  &metav1.Duration{Duration: time.Minute},
 }
 
 // +k8s:deepcopy-gen=true
@@ -43,6 +47,14 @@ type Settings struct {
 	TTLAfterNotRegistered *metav1.Duration
 	// This feature flag is temporary and will be removed in the near future.
 	DriftEnabled bool
+
+	// CloudProviderRetries is how many times calls to the cloud provider are retried when they fail transiently
+	CloudProviderRetries int
+	// CircuitBreakerThreshold is how many consecutive creates of a provisioner have to fail because the cloud provider
+	// is unavailable before further creates are rejected. Zero disables the circuit breaker.
+	CircuitBreakerThreshold int
+	// CircuitBreakerCooldown is how long creates are rejected for once the circuit breaker opens
+	CircuitBreakerCooldown *metav1.Duration
 }
 
 func (*Settings) ConfigMap() string {
@@ -58,6 +70,9 @@ func (*Settings) Inject(ctx context.Context, cm *v1.ConfigMap) (context.Context,
 		AsMetaDuration("batchIdleDuration", &s.BatchIdleDuration),
 		AsMetaDuration("ttlAfterNotRegistered", &s.TTLAfterNotRegistered),
 		configmap.AsBool("featureGates.driftEnabled", &s.DriftEnabled),
+		configmap.AsInt("cloudProvider.retries", &s.CloudProviderRetries),
+		configmap.AsInt("cloudProvider.circuitBreakerThreshold", &s.CircuitBreakerThreshold),
+		AsMetaDuration("cloudProvider.circuitBreakerCooldown", &s.CircuitBreakerCooldown),
 	); err != nil {
 		return ctx, fmt.Errorf("parsing settings, %w", err)
 	}
@@ -81,6 +96,17 @@ func (in *Settings) Validate() (err error) {
 	if in.TTLAfterNotRegistered != nil && in.TTLAfterNotRegistered.Duration <= 0 {
 		err = multierr.Append(err, fmt.Errorf("ttlAfterNotRegistered cannot be negative"))
 	}
+	if in.CloudProviderRetries < 0 {
+		err = multierr.Append(err, fmt.Errorf("cloudProvider.retries cannot be negative"))
+	}
+	if in.CircuitBreakerThreshold < 0 {
+		err = multierr.Append(err, fmt.Errorf("cloudProvider.circuitBreakerThreshold cannot be negative"))
+	}
+	if in.CircuitBreakerCooldown == nil {
+		err = multierr.Append(err, fmt.Errorf("cloudProvider.circuitBreakerCooldown is required"))
+	} else if in.CircuitBreakerCooldown.Duration <= 0 {
+		err = multierr.Append(err, fmt.Errorf("cloudProvider.circuitBreakerCooldown cannot be negative"))
+	}
 	return err
 }
 
@@ -47,14 +47,20 @@ var _ = Describe("Validation", func() {
 		Expect(s.BatchIdleDuration.Duration).To(Equal(time.Second))
 		Expect(s.DriftEnabled).To(BeFalse())
 		Expect(s.TTLAfterNotRegistered.Duration).To(Equal(time.Minute * 15))
+		Expect(s.CloudProviderRetries).To(Equal(3))
+		Expect(s.CircuitBreakerThreshold).To(Equal(5))
+		Expect(s.CircuitBreakerCooldown.Duration).To(Equal(time.Minute))
 	})
 	It("should succeed to set custom values", func() {
 		cm := &v1.ConfigMap{
 			Data: map[string]string{
-				"batchMaxDuration":          "30s",
-				"batchIdleDuration":         "5s",
-				"featureGates.driftEnabled": "true",
-				"ttlAfterNotRegistered":     "30m",
+				"batchMaxDuration":                      "30s",
+				"batchIdleDuration":                     "5s",
+				"featureGates.driftEnabled":             "true",
+				"ttlAfterNotRegistered":                 "30m",
+				"cloudProvider.retries":                 "1",
+				"cloudProvider.circuitBreakerThreshold": "0",
+				"cloudProvider.circuitBreakerCooldown":  "5m",
 			},
 		}
 		ctx, err := (&settings.Settings{}).Inject(ctx, cm)
@@ -64,6 +70,9 @@ var _ = Describe("Validation", func() {
 		Expect(s.BatchIdleDuration.Duration).To(Equal(time.Second * 5))
 		Expect(s.DriftEnabled).To(BeTrue())
 		Expect(s.TTLAfterNotRegistered.Duration).To(Equal(time.Minute * 30))
+		Expect(s.CloudProviderRetries).To(Equal(1))
+		Expect(s.CircuitBreakerThreshold).To(BeZero())
+		Expect(s.CircuitBreakerCooldown.Duration).To(Equal(time.Minute * 5))
 	})
 	It("should succeed to disable ttlAfterNotRegistered", func() {
 		cm := &v1.ConfigMap{
@@ -136,4 +145,22 @@ var _ = Describe("Validation", func() {
 		_, err := (&settings.Settings{}).Inject(ctx, cm)
 		Expect(err).To(HaveOccurred())
 	})
+	It("should fail validation when cloudProvider.retries is negative", func() {
+		cm := &v1.ConfigMap{
+			Data: map[string]string{
+				"cloudProvider.retries": "-1",
+			},
+		}
+		_, err := (&settings.Settings{}).Inject(ctx, cm)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should fail validation when cloudProvider.circuitBreakerCooldown is set to empty", func() {
+		cm := &v1.ConfigMap{
+			Data: map[string]string{
+				"cloudProvider.circuitBreakerCooldown": "",
+			},
+		}
+		_, err := (&settings.Settings{}).Inject(ctx, cm)
+		Expect(err).To(HaveOccurred())
+	})
 })
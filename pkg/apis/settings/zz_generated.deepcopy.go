@@ -41,6 +41,11 @@ func (in *Settings) DeepCopyInto(out *Settings) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.CircuitBreakerCooldown != nil {
+		in, out := &in.CircuitBreakerCooldown, &out.CircuitBreakerCooldown
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Settings.
@@ -0,0 +1,145 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha5
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var budgetNodesRegex = regexp.MustCompile(`^((100|[0-9]{1,2})%|[0-9]+)$`)
+
+// Disruption contains the parameters that govern voluntary disruption of the nodes launched by a provisioner
+type Disruption struct {
+	// Budgets is a list of Budgets. If there are multiple budgets, the most restrictive one is used.
+	// Voluntary disruption is not limited if no budgets are specified.
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	Budgets []Budget `json:"budgets,omitempty"`
+	// MaintenanceWindows is a list of recurring windows during which nodes can be voluntarily disrupted by
+	// expiration, drift and consolidation. Nodes may be disrupted at any time if no windows are specified.
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+}
+
+// Budget caps the number of nodes of a provisioner that can be voluntarily disrupted at the same time
+type Budget struct {
+	// Nodes is the maximum number of nodes that can be disrupted at once. It can be either an absolute
+	// count (e.g. "5") or a percentage of the nodes owned by the provisioner (e.g. "10%"). Percentages are
+	// rounded up so that a non-zero percentage always allows at least one node to be disrupted.
+	// +kubebuilder:validation:Pattern:="^((100|[0-9]{1,2})%|[0-9]+)$"
+	Nodes string `json:"nodes"`
+}
+
+// MaintenanceWindow is a recurring window of time during which nodes can be voluntarily disrupted
+type MaintenanceWindow struct {
+	// Schedule specifies when the window opens, in cron format (e.g. "0 22 * * 1-5"). Macros such as "@daily"
+	// are supported, "@every" is not.
+	Schedule string `json:"schedule"`
+	// TimeZone is the IANA name of the time zone that the schedule is evaluated in. Defaults to UTC.
+	// +optional
+	TimeZone *string `json:"timeZone,omitempty"`
+	// Duration is how long the window stays open after each time it is opened by the schedule.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	Duration metav1.Duration `json:"duration"`
+}
+
+// AllowedDisruptions returns the number of nodes that can be disrupted at once given the total number of nodes owned
+// by the provisioner. If there are no budgets, disruption is not limited.
+func (d *Disruption) AllowedDisruptions(numNodes int) (int, error) {
+	if d == nil || len(d.Budgets) == 0 {
+		return math.MaxInt32, nil
+	}
+	allowed := math.MaxInt32
+	for i := range d.Budgets {
+		n, err := d.Budgets[i].AllowedDisruptions(numNodes)
+		if err != nil {
+			return 0, err
+		}
+		if n < allowed {
+			allowed = n
+		}
+	}
+	return allowed, nil
+}
+
+// AllowedDisruptions returns the number of nodes that the budget allows to be disrupted at once given the total number
+// of nodes owned by the provisioner.
+func (b *Budget) AllowedDisruptions(numNodes int) (int, error) {
+	if !budgetNodesRegex.MatchString(b.Nodes) {
+		return 0, fmt.Errorf("invalid budget nodes value %q", b.Nodes)
+	}
+	nodes := intstr.Parse(b.Nodes)
+	return intstr.GetScaledValueFromIntOrPercent(&nodes, numNodes, true)
+}
+
+// InMaintenanceWindow returns true if one of the maintenance windows is open at the given time. If there are no
+// maintenance windows, disruption is allowed at any time.
+func (d *Disruption) InMaintenanceWindow(now time.Time) (bool, error) {
+	if d == nil || len(d.MaintenanceWindows) == 0 {
+		return true, nil
+	}
+	for i := range d.MaintenanceWindows {
+		open, err := d.MaintenanceWindows[i].IsOpen(now)
+		if err != nil {
+			return false, err
+		}
+		if open {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsOpen returns true if the window is open at the given time
+func (w *MaintenanceWindow) IsOpen(now time.Time) (bool, error) {
+	schedule, loc, err := w.parse()
+	if err != nil {
+		return false, err
+	}
+	// The window is open if the schedule fired within the last duration. Next returns the first activation strictly
+	// after the given time, so we look for an activation after the earliest time that the window could have opened.
+	next := schedule.Next(now.In(loc).Add(-w.Duration.Duration))
+	return !next.After(now), nil
+}
+
+func (w *MaintenanceWindow) parse() (cron.Schedule, *time.Location, error) {
+	if strings.HasPrefix(w.Schedule, "@every") {
+		return nil, nil, fmt.Errorf("unsupported schedule %q, @every is not supported", w.Schedule)
+	}
+	if strings.Contains(w.Schedule, "TZ=") {
+		return nil, nil, fmt.Errorf("unsupported schedule %q, use timeZone to specify a time zone", w.Schedule)
+	}
+	schedule, err := cron.ParseStandard(w.Schedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing schedule %q, %w", w.Schedule, err)
+	}
+	loc := time.UTC
+	if w.TimeZone != nil {
+		if loc, err = time.LoadLocation(*w.TimeZone); err != nil {
+			return nil, nil, fmt.Errorf("loading time zone %q, %w", *w.TimeZone, err)
+		}
+	}
+	return schedule, loc, nil
+}
@@ -28,6 +28,7 @@ const (
 	ArchitectureArm64    = "arm64"
 	CapacityTypeSpot     = "spot"
 	CapacityTypeOnDemand = "on-demand"
+	CapacityTypeReserved = "reserved"
 )
 
 // Karpenter specific domains and labels
@@ -44,6 +45,8 @@ const (
 	DoNotConsolidateNodeAnnotationKey = Group + "/do-not-consolidate"
 	EmptinessTimestampAnnotationKey   = Group + "/emptiness-timestamp"
 	VoluntaryDisruptionAnnotationKey  = Group + "/voluntary-disruption"
+	ProvisionerHashAnnotationKey      = Group + "/provisioner-hash"
+	ExpirationTimeAnnotationKey       = Group + "/expiration-time"
 
 	ProviderCompatabilityAnnotationKey = CompatabilityGroup + "/provider"
 
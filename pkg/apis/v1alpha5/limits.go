@@ -24,14 +24,124 @@ import (
 type Limits struct {
 	// Resources contains all the allocatable resources that Karpenter supports for limiting.
 	Resources v1.ResourceList `json:"resources,omitempty"`
+	// Nodes is the maximum number of nodes that the provisioner can have at once.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	Nodes *int64 `json:"nodes,omitempty"`
+	// Scoped are limits that only apply to the nodes of the provisioner that are in a single zone or of a single
+	// capacity type.
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	Scoped []ScopedLimit `json:"scoped,omitempty"`
+}
+
+// ScopedLimit defines bounds on the resources and nodes in a single zone or of a single capacity type. Exactly one of
+// zone and capacityType must be set.
+type ScopedLimit struct {
+	// Zone scopes the limit to the nodes in the zone.
+	// +optional
+	Zone *string `json:"zone,omitempty"`
+	// CapacityType scopes the limit to the nodes of the capacity type.
+	// +optional
+	CapacityType *string `json:"capacityType,omitempty"`
+	// Resources contains all the allocatable resources that Karpenter supports for limiting.
+	// +optional
+	Resources v1.ResourceList `json:"resources,omitempty"`
+	// Nodes is the maximum number of nodes that the provisioner can have at once within the scope.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	Nodes *int64 `json:"nodes,omitempty"`
+}
+
+// MinimumCapacity defines the floor of the capacity that is kept running for a provisioner
+type MinimumCapacity struct {
+	// Nodes is the minimum number of nodes that the provisioner keeps running.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	Nodes *int64 `json:"nodes,omitempty"`
+	// Resources is the minimum allocatable resources of the nodes that the provisioner keeps running.
+	// +optional
+	Resources v1.ResourceList `json:"resources,omitempty"`
+}
+
+// SatisfiedBy returns true if the nodes and their allocatable resources meet the minimum capacity
+func (m *MinimumCapacity) SatisfiedBy(resources v1.ResourceList, nodes int64) bool {
+	if m == nil {
+		return true
+	}
+	if m.Nodes != nil && nodes < *m.Nodes {
+		return false
+	}
+	for resourceName, minimum := range m.Resources {
+		if quantity, ok := resources[resourceName]; !ok || quantity.Cmp(minimum) < 0 {
+			return false
+		}
+	}
+	return true
 }
 
 func (l *Limits) ExceededBy(resources v1.ResourceList) error {
 	if l == nil || l.Resources == nil {
 		return nil
 	}
+	return exceededBy(l.Resources, resources)
+}
+
+// ExceededByNodes returns an error if the number of nodes exceeds the node limit
+func (l *Limits) ExceededByNodes(nodes int64) error {
+	if l == nil || l.Nodes == nil {
+		return nil
+	}
+	if nodes > *l.Nodes {
+		return fmt.Errorf("node count of %d exceeds limit of %d", nodes, *l.Nodes)
+	}
+	return nil
+}
+
+// ReachedBy returns an error if the resources or the number of nodes reached any of the limits, in which case no
+// more nodes can be launched. Resource limits of zero are only reached once they are exceeded, as they are used to
+// keep nodes with the resource from being launched rather than to block all nodes.
+func (l *Limits) ReachedBy(resources v1.ResourceList, nodes int64) error {
+	if l == nil {
+		return nil
+	}
+	if l.Nodes != nil && nodes >= *l.Nodes {
+		return fmt.Errorf("node count of %d reached limit of %d", nodes, *l.Nodes)
+	}
+	for resourceName, limit := range l.Resources {
+		if usage := resources[resourceName]; usage.Cmp(limit) > 0 || (!limit.IsZero() && usage.Cmp(limit) == 0) {
+			return fmt.Errorf("%s resource usage of %v reached limit of %v", resourceName, usage.AsDec(), limit.AsDec())
+		}
+	}
+	return nil
+}
+
+// Scope returns the label key and value of the nodes that the limit applies to
+func (s *ScopedLimit) Scope() (string, string) {
+	if s.Zone != nil {
+		return v1.LabelTopologyZone, *s.Zone
+	}
+	if s.CapacityType != nil {
+		return LabelCapacityType, *s.CapacityType
+	}
+	return "", ""
+}
+
+// ExceededBy returns an error if the resources or the number of nodes within the scope exceed the limit
+func (s *ScopedLimit) ExceededBy(resources v1.ResourceList, nodes int64) error {
+	key, value := s.Scope()
+	if s.Nodes != nil && nodes > *s.Nodes {
+		return fmt.Errorf("%s=%s node count of %d exceeds limit of %d", key, value, nodes, *s.Nodes)
+	}
+	if err := exceededBy(s.Resources, resources); err != nil {
+		return fmt.Errorf("%s=%s %w", key, value, err)
+	}
+	return nil
+}
+
+func exceededBy(limits v1.ResourceList, resources v1.ResourceList) error {
 	for resourceName, usage := range resources {
-		if limit, ok := l.Resources[resourceName]; ok {
+		if limit, ok := limits[resourceName]; ok {
 			if usage.Cmp(limit) > 0 {
 				return fmt.Errorf("%s resource usage of %v exceeds limit of %v", resourceName, usage.AsDec(), limit.AsDec())
 			}
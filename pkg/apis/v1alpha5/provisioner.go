@@ -16,8 +16,10 @@ package v1alpha5
 
 import (
 	"encoding/json"
+	"fmt"
 	"sort"
 
+	"github.com/mitchellh/hashstructure/v2"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -49,7 +51,7 @@ type ProvisionerSpec struct {
 	// +optional
 	StartupTaints []v1.Taint `json:"startupTaints,omitempty"`
 	// Requirements are layered with Labels and applied to every node.
-	Requirements []v1.NodeSelectorRequirement `json:"requirements,omitempty"`
+	Requirements []NodeSelectorRequirementWithMinValues `json:"requirements,omitempty"`
 	// KubeletConfiguration are options passed to the kubelet when provisioning nodes
 	//+optional
 	KubeletConfiguration *KubeletConfiguration `json:"kubeletConfiguration,omitempty"`
@@ -76,8 +78,19 @@ type ProvisionerSpec struct {
 	// Termination due to expiration is disabled if this field is not set.
 	// +optional
 	TTLSecondsUntilExpired *int64 `json:"ttlSecondsUntilExpired,omitempty"`
+	// TTLSecondsUntilExpiredJitter spreads the expiration of nodes over a window of this many seconds after
+	// TTLSecondsUntilExpired, so that nodes that are launched together don't all expire at the same time. Each node
+	// is given a deterministic offset within the window based on its name.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	TTLSecondsUntilExpiredJitter *int64 `json:"ttlSecondsUntilExpiredJitter,omitempty"`
 	// Limits define a set of bounds for provisioning capacity.
 	Limits *Limits `json:"limits,omitempty"`
+	// MinimumCapacity is the capacity that is kept running for the provisioner even when there are no pods that need
+	// it, so that pods don't have to wait for new nodes to launch. Nodes that make up the minimum capacity aren't
+	// removed by emptiness or consolidation.
+	// +optional
+	MinimumCapacity *MinimumCapacity `json:"minimumCapacity,omitempty"`
 	// Weight is the priority given to the provisioner during scheduling. A higher
 	// numerical weight indicates that this provisioner will be ordered
 	// ahead of other provisioners with lower weights. A provisioner with no weight
@@ -89,13 +102,71 @@ type ProvisionerSpec struct {
 	// Consolidation are the consolidation parameters
 	// +optional
 	Consolidation *Consolidation `json:"consolidation,omitempty"`
+	// Paused stops the provisioner from launching nodes and from voluntarily disrupting the nodes that it owns, e.g.
+	// while a cloud provider outage is being mitigated. Existing nodes keep running.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// Disruption contains the parameters that limit voluntary disruption (expiration, drift, emptiness and
+	// consolidation) of the nodes launched by this provisioner
+	// +optional
+	Disruption *Disruption `json:"disruption,omitempty"`
 }
 
 type Consolidation struct {
 	// Enabled enables consolidation if it has been set
 	Enabled *bool `json:"enabled,omitempty"`
+	// Policy describes which nodes consolidation will consider. WhenEmpty only deletes nodes that have no pods
+	// other than daemonsets, WhenUnderutilized also deletes or replaces nodes that are underutilized. Defaults
+	// to WhenUnderutilized.
+	// +kubebuilder:validation:Enum:={WhenEmpty,WhenUnderutilized}
+	// +optional
+	Policy ConsolidationPolicy `json:"policy,omitempty"`
+	// UtilizationThreshold is the percentage of a node's allocatable CPU and memory that can be requested by pods
+	// for the node to still be considered for consolidation. Nodes where either resource is requested above the
+	// threshold are not consolidated. All nodes are considered if this field is not set.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	UtilizationThreshold *int32 `json:"utilizationThreshold,omitempty"`
+	// MinimumSavingsPercent is how much cheaper, in percent, a replacement node has to be than the nodes that it
+	// replaces for consolidation to replace them. Any cheaper replacement is used if this field is not set.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	MinimumSavingsPercent *int32 `json:"minimumSavingsPercent,omitempty"`
+}
+
+// NodeSelectorRequirementWithMinValues is a requirement that can also ask for a minimum number of distinct values of
+// its key, e.g. node.kubernetes.io/instance-type, across the instance types that a node can launch with. Nodes aren't
+// launched if their instance type options are narrower than this, which keeps launches flexible enough to avoid
+// capacity shortages and interruptions.
+type NodeSelectorRequirementWithMinValues struct {
+	v1.NodeSelectorRequirement `json:",inline"`
+	// MinValues is the minimum number of distinct values of the key across the instance types a node can launch with
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=50
+	// +optional
+	MinValues *int `json:"minValues,omitempty"`
+}
+
+// NodeSelectorRequirements returns the requirements of the provisioner without their minimum values
+func (s *ProvisionerSpec) NodeSelectorRequirements() []v1.NodeSelectorRequirement {
+	if s.Requirements == nil {
+		return nil
+	}
+	return lo.Map(s.Requirements, func(r NodeSelectorRequirementWithMinValues, _ int) v1.NodeSelectorRequirement {
+		return r.NodeSelectorRequirement
+	})
 }
 
+// ConsolidationPolicy describes which nodes consolidation will consider
+type ConsolidationPolicy string
+
+const (
+	ConsolidationPolicyWhenEmpty         ConsolidationPolicy = "WhenEmpty"
+	ConsolidationPolicyWhenUnderutilized ConsolidationPolicy = "WhenUnderutilized"
+)
+
 // +kubebuilder:object:generate=false
 type Provider = runtime.RawExtension
 
@@ -111,6 +182,13 @@ func ProviderAnnotation(p *Provider) map[string]string {
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=provisioners,scope=Cluster,categories=karpenter
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Nodes",type="integer",JSONPath=".status.nodes",description=""
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+// +kubebuilder:printcolumn:name="Paused",type="boolean",JSONPath=".spec.paused",description=""
+// +kubebuilder:printcolumn:name="Blocked",type="string",JSONPath=".status.conditions[?(@.type==\"ProvisioningBlocked\")].status",description=""
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"ProvisioningBlocked\")].reason",priority=1,description=""
+// +kubebuilder:printcolumn:name="Last Launch",type="date",JSONPath=".status.lastLaunchTime",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
 type Provisioner struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -119,6 +197,26 @@ type Provisioner struct {
 	Status ProvisionerStatus `json:"status,omitempty"`
 }
 
+// Hash returns a hash of the fields of the provisioner that are used to launch nodes. Nodes are stamped with the hash
+// when they are launched, and are considered drifted once it no longer matches the hash of their provisioner.
+func (p *Provisioner) Hash() string {
+	return fmt.Sprint(lo.Must(hashstructure.Hash(struct {
+		Requirements         []v1.NodeSelectorRequirement
+		Labels               map[string]string
+		Taints               []v1.Taint
+		StartupTaints        []v1.Taint
+		KubeletConfiguration *KubeletConfiguration
+		ProviderRef          *ProviderRef
+	}{
+		Requirements:         p.Spec.NodeSelectorRequirements(),
+		Labels:               p.Spec.Labels,
+		Taints:               p.Spec.Taints,
+		StartupTaints:        p.Spec.StartupTaints,
+		KubeletConfiguration: p.Spec.KubeletConfiguration,
+		ProviderRef:          p.Spec.ProviderRef,
+	}, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true, IgnoreZeroValue: true, ZeroNil: true})))
+}
+
 // ProvisionerList contains a list of Provisioner
 // +kubebuilder:object:root=true
 type ProvisionerList struct {
@@ -34,14 +34,37 @@ type ProvisionerStatus struct {
 
 	// Resources is the list of resources that have been provisioned.
 	Resources v1.ResourceList `json:"resources,omitempty"`
+
+	// Nodes is the number of nodes that have been provisioned.
+	// +optional
+	Nodes int64 `json:"nodes,omitempty"`
+
+	// LastLaunchTime is the last time that the Provisioner successfully launched a node
+	// +optional
+	// +kubebuilder:validation:Format="date-time"
+	LastLaunchTime *apis.VolatileTime `json:"lastLaunchTime,omitempty"`
 }
 
+// StatusConditions manages the conditions of the Provisioner. The Provisioner is Ready when instance types are
+// available and the cloud provider is healthy. LimitsExceeded, Paused and ProvisioningBlocked are informational and
+// explain why the Provisioner isn't launching nodes. MaintenanceWindowsValid is informational too, and explains why the
+// nodes of the Provisioner aren't voluntarily disrupted.
 func (p *Provisioner) StatusConditions() apis.ConditionManager {
 	return apis.NewLivingConditionSet(
-		Active,
+		ProvisionerInstanceTypesAvailable,
+		ProvisionerCloudProviderHealthy,
 	).Manage(p)
 }
 
+var (
+	ProvisionerLimitsExceeded          apis.ConditionType = "LimitsExceeded"
+	ProvisionerPaused                  apis.ConditionType = "Paused"
+	ProvisionerInstanceTypesAvailable  apis.ConditionType = "InstanceTypesAvailable"
+	ProvisionerCloudProviderHealthy    apis.ConditionType = "CloudProviderHealthy"
+	ProvisionerProvisioningBlocked     apis.ConditionType = "ProvisioningBlocked"
+	ProvisionerMaintenanceWindowsValid apis.ConditionType = "MaintenanceWindowsValid"
+)
+
 func (p *Provisioner) GetConditions() apis.Conditions {
 	return p.Status.Conditions
 }
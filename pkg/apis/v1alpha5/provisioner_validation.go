@@ -73,6 +73,10 @@ func (s *ProvisionerSpec) validate(ctx context.Context) (errs *apis.FieldError)
 	return errs.Also(
 		s.validateTTLSecondsUntilExpired(),
 		s.validateTTLSecondsAfterEmpty(),
+		s.validateDisruption().ViaField("disruption"),
+		s.validateConsolidation().ViaField("consolidation"),
+		s.validateLimits().ViaField("limits"),
+		s.validateMinimumCapacity().ViaField("minimumCapacity"),
 		s.Validate(ctx),
 	)
 }
@@ -81,6 +85,9 @@ func (s *ProvisionerSpec) validateTTLSecondsUntilExpired() (errs *apis.FieldErro
 	if ptr.Int64Value(s.TTLSecondsUntilExpired) < 0 {
 		return errs.Also(apis.ErrInvalidValue("cannot be negative", "ttlSecondsUntilExpired"))
 	}
+	if ptr.Int64Value(s.TTLSecondsUntilExpiredJitter) < 0 {
+		return errs.Also(apis.ErrInvalidValue("cannot be negative", "ttlSecondsUntilExpiredJitter"))
+	}
 	return errs
 }
 
@@ -95,6 +102,84 @@ func (s *ProvisionerSpec) validateTTLSecondsAfterEmpty() (errs *apis.FieldError)
 	return errs
 }
 
+func (s *ProvisionerSpec) validateLimits() (errs *apis.FieldError) {
+	if s.Limits == nil {
+		return errs
+	}
+	if ptr.Int64Value(s.Limits.Nodes) < 0 {
+		errs = errs.Also(apis.ErrInvalidValue("cannot be negative", "nodes"))
+	}
+	for i, scoped := range s.Limits.Scoped {
+		if (scoped.Zone == nil) == (scoped.CapacityType == nil) {
+			errs = errs.Also(apis.ErrMissingOneOf("zone", "capacityType").ViaFieldIndex("scoped", i))
+		}
+		if ptr.Int64Value(scoped.Nodes) < 0 {
+			errs = errs.Also(apis.ErrInvalidValue("cannot be negative", "nodes").ViaFieldIndex("scoped", i))
+		}
+	}
+	return errs
+}
+
+func (s *ProvisionerSpec) validateMinimumCapacity() (errs *apis.FieldError) {
+	if s.MinimumCapacity == nil {
+		return errs
+	}
+	if nodes := ptr.Int64Value(s.MinimumCapacity.Nodes); nodes < 0 {
+		errs = errs.Also(apis.ErrInvalidValue("cannot be negative", "nodes"))
+	} else if s.Limits != nil && s.Limits.Nodes != nil && nodes > *s.Limits.Nodes {
+		errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%d, exceeds the node limit of %d", nodes, *s.Limits.Nodes), "nodes"))
+	}
+	for name, quantity := range s.MinimumCapacity.Resources {
+		if quantity.Sign() < 0 {
+			errs = errs.Also(apis.ErrInvalidValue("cannot be negative", fmt.Sprintf("resources[%s]", name)))
+		} else if s.Limits != nil {
+			if limit, ok := s.Limits.Resources[name]; ok && quantity.Cmp(limit) > 0 {
+				errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s, exceeds the limit of %s", quantity.String(), limit.String()), fmt.Sprintf("resources[%s]", name)))
+			}
+		}
+	}
+	return errs
+}
+
+func (s *ProvisionerSpec) validateConsolidation() (errs *apis.FieldError) {
+	if s.Consolidation == nil {
+		return errs
+	}
+	if s.Consolidation.Policy != "" &&
+		s.Consolidation.Policy != ConsolidationPolicyWhenEmpty &&
+		s.Consolidation.Policy != ConsolidationPolicyWhenUnderutilized {
+		errs = errs.Also(apis.ErrInvalidValue(s.Consolidation.Policy, "policy"))
+	}
+	if threshold := s.Consolidation.UtilizationThreshold; threshold != nil && (*threshold < 1 || *threshold > 100) {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(*threshold, 1, 100, "utilizationThreshold"))
+	}
+	if savings := s.Consolidation.MinimumSavingsPercent; savings != nil && (*savings < 0 || *savings > 100) {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(*savings, 0, 100, "minimumSavingsPercent"))
+	}
+	return errs
+}
+
+func (s *ProvisionerSpec) validateDisruption() (errs *apis.FieldError) {
+	if s.Disruption == nil {
+		return errs
+	}
+	for i, budget := range s.Disruption.Budgets {
+		if !budgetNodesRegex.MatchString(budget.Nodes) {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s, must be a non-negative integer or a percentage", budget.Nodes), "nodes").ViaFieldIndex("budgets", i))
+		}
+	}
+	for i := range s.Disruption.MaintenanceWindows {
+		window := &s.Disruption.MaintenanceWindows[i]
+		if _, _, err := window.parse(); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(err.Error(), "schedule").ViaFieldIndex("maintenanceWindows", i))
+		}
+		if window.Duration.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue("must be positive", "duration").ViaFieldIndex("maintenanceWindows", i))
+		}
+	}
+	return errs
+}
+
 // Validate the constraints
 func (s *ProvisionerSpec) Validate(ctx context.Context) (errs *apis.FieldError) {
 	return errs.Also(
@@ -102,6 +187,7 @@ func (s *ProvisionerSpec) Validate(ctx context.Context) (errs *apis.FieldError)
 		s.validateLabels(),
 		s.validateTaints(),
 		s.validateRequirements(),
+		s.validateMinValues(),
 		s.validateKubeletConfiguration().ViaField("kubeletConfiguration"),
 	)
 }
@@ -124,6 +210,22 @@ func (s *ProvisionerSpec) validateLabels() (errs *apis.FieldError) {
 	return errs
 }
 
+func (s *ProvisionerSpec) validateMinValues() (errs *apis.FieldError) {
+	for i, requirement := range s.Requirements {
+		if requirement.MinValues == nil {
+			continue
+		}
+		if *requirement.MinValues < 1 || *requirement.MinValues > 50 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(*requirement.MinValues, 1, 50, "minValues").ViaFieldIndex("requirements", i))
+		}
+		// the requirement can't restrict the key to fewer values than the minimum
+		if requirement.Operator == v1.NodeSelectorOpIn && len(requirement.Values) < *requirement.MinValues {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%d, requirement only allows %d values", *requirement.MinValues, len(requirement.Values)), "minValues").ViaFieldIndex("requirements", i))
+		}
+	}
+	return errs
+}
+
 type taintKeyEffect struct {
 	Key    string
 	Effect v1.TaintEffect
@@ -178,7 +280,7 @@ func (s *ProvisionerSpec) validateRequirements() (errs *apis.FieldError) {
 		if requirement.Key == ProvisionerNameLabelKey {
 			errs = errs.Also(apis.ErrInvalidArrayValue(fmt.Sprintf("%s is restricted", requirement.Key), "requirements", i))
 		}
-		if err := ValidateRequirement(requirement); err != nil {
+		if err := ValidateRequirement(requirement.NodeSelectorRequirement); err != nil {
 			errs = errs.Also(apis.ErrInvalidArrayValue(err, "requirements", i))
 		}
 	}
@@ -46,5 +46,8 @@ const (
 	// Active is a condition implemented by all resources. It indicates that the
 	// controller is able to take actions: it's correctly configured, can make
 	// necessary API calls, and isn't disabled.
+	//
+	// Deprecated: Active isn't set on provisioners. Use apis.ConditionReady, which summarizes the provisioner
+	// conditions, instead.
 	Active apis.ConditionType = "Active"
 )
@@ -16,6 +16,7 @@ package v1alpha5
 
 import (
 	"context"
+	"math"
 	"strings"
 	"testing"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"github.com/Pallinder/go-randomdata"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 	. "knative.dev/pkg/logging/testing"
 	"knative.dev/pkg/ptr"
 
@@ -65,6 +67,16 @@ var _ = Describe("Validation", func() {
 		provisioner.Spec.TTLSecondsUntilExpired = nil
 		Expect(provisioner.Validate(ctx)).To(Succeed())
 	})
+	It("should fail on negative expiry jitter", func() {
+		provisioner.Spec.TTLSecondsUntilExpired = ptr.Int64(30)
+		provisioner.Spec.TTLSecondsUntilExpiredJitter = ptr.Int64(-1)
+		Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+	})
+	It("should succeed on an expiry jitter", func() {
+		provisioner.Spec.TTLSecondsUntilExpired = ptr.Int64(30)
+		provisioner.Spec.TTLSecondsUntilExpiredJitter = ptr.Int64(3600)
+		Expect(provisioner.Validate(ctx)).To(Succeed())
+	})
 	It("should fail on negative empty ttl", func() {
 		provisioner.Spec.TTLSecondsAfterEmpty = ptr.Int64(-1)
 		Expect(provisioner.Validate(ctx)).ToNot(Succeed())
@@ -102,6 +114,167 @@ var _ = Describe("Validation", func() {
 			provisioner.Spec.Limits = &Limits{Resources: v1.ResourceList{}}
 			Expect(provisioner.Validate(ctx)).To(Succeed())
 		})
+		It("should allow node and scoped limits", func() {
+			provisioner.Spec.Limits = &Limits{
+				Nodes: ptr.Int64(10),
+				Scoped: []ScopedLimit{
+					{Zone: ptr.String("test-zone-1"), Nodes: ptr.Int64(5)},
+					{CapacityType: ptr.String(CapacityTypeOnDemand), Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("200")}},
+				},
+			}
+			Expect(provisioner.Validate(ctx)).To(Succeed())
+		})
+		It("should fail on a negative node limit", func() {
+			provisioner.Spec.Limits = &Limits{Nodes: ptr.Int64(-1)}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on a scoped limit without a scope", func() {
+			provisioner.Spec.Limits = &Limits{Scoped: []ScopedLimit{{Nodes: ptr.Int64(1)}}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on a scoped limit with both a zone and a capacity type", func() {
+			provisioner.Spec.Limits = &Limits{Scoped: []ScopedLimit{{Zone: ptr.String("test-zone-1"), CapacityType: ptr.String(CapacityTypeSpot)}}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on a negative scoped node limit", func() {
+			provisioner.Spec.Limits = &Limits{Scoped: []ScopedLimit{{Zone: ptr.String("test-zone-1"), Nodes: ptr.Int64(-1)}}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("MinimumCapacity", func() {
+		It("should allow a minimum node count and resources", func() {
+			provisioner.Spec.MinimumCapacity = &MinimumCapacity{Nodes: ptr.Int64(2), Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")}}
+			Expect(provisioner.Validate(ctx)).To(Succeed())
+		})
+		It("should fail on a negative node count", func() {
+			provisioner.Spec.MinimumCapacity = &MinimumCapacity{Nodes: ptr.Int64(-1)}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on negative resources", func() {
+			provisioner.Spec.MinimumCapacity = &MinimumCapacity{Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("-1")}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on a node count above the node limit", func() {
+			provisioner.Spec.Limits = &Limits{Nodes: ptr.Int64(2)}
+			provisioner.Spec.MinimumCapacity = &MinimumCapacity{Nodes: ptr.Int64(3)}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on resources above the resource limits", func() {
+			provisioner.Spec.Limits = &Limits{Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")}}
+			provisioner.Spec.MinimumCapacity = &MinimumCapacity{Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("Consolidation", func() {
+		It("should allow valid consolidation parameters", func() {
+			provisioner.Spec.Consolidation = &Consolidation{
+				Enabled:               ptr.Bool(true),
+				Policy:                ConsolidationPolicyWhenUnderutilized,
+				UtilizationThreshold:  ptr.Int32(50),
+				MinimumSavingsPercent: ptr.Int32(20),
+			}
+			Expect(provisioner.Validate(ctx)).To(Succeed())
+			provisioner.Spec.Consolidation.Policy = ConsolidationPolicyWhenEmpty
+			Expect(provisioner.Validate(ctx)).To(Succeed())
+		})
+		It("should fail on an unknown policy", func() {
+			provisioner.Spec.Consolidation = &Consolidation{Enabled: ptr.Bool(true), Policy: "Always"}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on an out of bounds utilization threshold", func() {
+			provisioner.Spec.Consolidation = &Consolidation{Enabled: ptr.Bool(true), UtilizationThreshold: ptr.Int32(0)}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+			provisioner.Spec.Consolidation.UtilizationThreshold = ptr.Int32(101)
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on an out of bounds minimum savings percentage", func() {
+			provisioner.Spec.Consolidation = &Consolidation{Enabled: ptr.Bool(true), MinimumSavingsPercent: ptr.Int32(-1)}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+			provisioner.Spec.Consolidation.MinimumSavingsPercent = ptr.Int32(101)
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("MinValues", func() {
+		It("should allow minValues", func() {
+			provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"a", "b", "c"}}, MinValues: lo.ToPtr(3)},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpExists}, MinValues: lo.ToPtr(2)},
+			}
+			Expect(provisioner.Validate(ctx)).To(Succeed())
+		})
+		It("should fail on minValues out of bounds", func() {
+			provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpExists}, MinValues: lo.ToPtr(0)},
+			}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+			provisioner.Spec.Requirements[0].MinValues = lo.ToPtr(51)
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail if the requirement allows fewer values than the minimum", func() {
+			provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"a", "b"}}, MinValues: lo.ToPtr(3)},
+			}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("Disruption", func() {
+		It("should allow undefined budgets", func() {
+			provisioner.Spec.Disruption = &Disruption{}
+			Expect(provisioner.Validate(ctx)).To(Succeed())
+		})
+		It("should allow absolute and percentage budgets", func() {
+			provisioner.Spec.Disruption = &Disruption{Budgets: []Budget{{Nodes: "0"}, {Nodes: "15"}, {Nodes: "0%"}, {Nodes: "100%"}}}
+			Expect(provisioner.Validate(ctx)).To(Succeed())
+		})
+		It("should fail on a negative budget", func() {
+			provisioner.Spec.Disruption = &Disruption{Budgets: []Budget{{Nodes: "-1"}}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on a percentage over 100", func() {
+			provisioner.Spec.Disruption = &Disruption{Budgets: []Budget{{Nodes: "101%"}}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on a malformed budget", func() {
+			provisioner.Spec.Disruption = &Disruption{Budgets: []Budget{{Nodes: "ten"}}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should allow valid maintenance windows", func() {
+			provisioner.Spec.Disruption = &Disruption{MaintenanceWindows: []MaintenanceWindow{
+				{Schedule: "0 22 * * 1-5", Duration: metav1.Duration{Duration: 8 * time.Hour}},
+				{Schedule: "@daily", TimeZone: ptr.String("Europe/Berlin"), Duration: metav1.Duration{Duration: time.Hour}},
+			}}
+			Expect(provisioner.Validate(ctx)).To(Succeed())
+		})
+		It("should fail on an invalid schedule", func() {
+			provisioner.Spec.Disruption = &Disruption{MaintenanceWindows: []MaintenanceWindow{
+				{Schedule: "0 25 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on an @every schedule", func() {
+			provisioner.Spec.Disruption = &Disruption{MaintenanceWindows: []MaintenanceWindow{
+				{Schedule: "@every 1h", Duration: metav1.Duration{Duration: time.Hour}},
+			}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on a schedule with an embedded time zone", func() {
+			provisioner.Spec.Disruption = &Disruption{MaintenanceWindows: []MaintenanceWindow{
+				{Schedule: "CRON_TZ=Europe/Berlin 0 22 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on an unknown time zone", func() {
+			provisioner.Spec.Disruption = &Disruption{MaintenanceWindows: []MaintenanceWindow{
+				{Schedule: "@daily", TimeZone: ptr.String("Mars/Olympus_Mons"), Duration: metav1.Duration{Duration: time.Hour}},
+			}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on a missing duration", func() {
+			provisioner.Spec.Disruption = &Disruption{MaintenanceWindows: []MaintenanceWindow{
+				{Schedule: "@daily"},
+			}}
+			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
+		})
 	})
 	Context("Provider", func() {
 		It("should not allow provider and providerRef", func() {
@@ -203,62 +376,62 @@ var _ = Describe("Validation", func() {
 	})
 	Context("Requirements", func() {
 		It("should fail for the provisioner name label", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: ProvisionerNameLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{randomdata.SillyName()}},
+			provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: ProvisionerNameLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{randomdata.SillyName()}}},
 			}
 			Expect(provisioner.Validate(ctx)).ToNot(Succeed())
 		})
 		It("should allow supported ops", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test"}},
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpGt, Values: []string{"1"}},
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpLt, Values: []string{"1"}},
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpNotIn},
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpExists},
+			provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpGt, Values: []string{"1"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpLt, Values: []string{"1"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpNotIn}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpExists}},
 			}
 			Expect(provisioner.Validate(ctx)).To(Succeed())
 		})
 		It("should fail for unsupported ops", func() {
 			for _, op := range []v1.NodeSelectorOperator{"unknown"} {
-				provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-					{Key: v1.LabelTopologyZone, Operator: op, Values: []string{"test"}},
+				provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: op, Values: []string{"test"}}},
 				}
 				Expect(provisioner.Validate(ctx)).ToNot(Succeed())
 			}
 		})
 		It("should fail for restricted domains", func() {
 			for label := range RestrictedLabelDomains {
-				provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-					{Key: label + "/test", Operator: v1.NodeSelectorOpIn, Values: []string{"test"}},
+				provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: label + "/test", Operator: v1.NodeSelectorOpIn, Values: []string{"test"}}},
 				}
 				Expect(provisioner.Validate(ctx)).ToNot(Succeed())
 			}
 		})
 		It("should allow restricted domains exceptions", func() {
 			for label := range LabelDomainExceptions {
-				provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-					{Key: label + "/test", Operator: v1.NodeSelectorOpIn, Values: []string{"test"}},
+				provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: label + "/test", Operator: v1.NodeSelectorOpIn, Values: []string{"test"}}},
 				}
 				Expect(provisioner.Validate(ctx)).To(Succeed())
 			}
 		})
 		It("should allow well known label exceptions", func() {
 			for label := range WellKnownLabels.Difference(sets.NewString(ProvisionerNameLabelKey)) {
-				provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-					{Key: label, Operator: v1.NodeSelectorOpIn, Values: []string{"test"}},
+				provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: label, Operator: v1.NodeSelectorOpIn, Values: []string{"test"}}},
 				}
 				Expect(provisioner.Validate(ctx)).To(Succeed())
 			}
 		})
 		It("should allow non-empty set after removing overlapped value", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test", "foo"}},
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpNotIn, Values: []string{"test", "bar"}},
+			provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test", "foo"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpNotIn, Values: []string{"test", "bar"}}},
 			}
 			Expect(provisioner.Validate(ctx)).To(Succeed())
 		})
 		It("should allow empty requirements", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{}
+			provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{}
 			Expect(provisioner.Validate(ctx)).To(Succeed())
 		})
 		It("should fail with invalid GT or LT values", func() {
@@ -272,7 +445,7 @@ var _ = Describe("Validation", func() {
 				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpLt, Values: []string{"a"}},
 				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpLt, Values: []string{"-1"}},
 			} {
-				provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{requirement}
+				provisioner.Spec.Requirements = []NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: requirement}}
 				Expect(provisioner.Validate(ctx)).ToNot(Succeed())
 			}
 		})
@@ -520,4 +693,132 @@ var _ = Describe("Limits", func() {
 		provisioner.Status.Resources = v1.ResourceList{"cpu": resource.MustParse("17")}
 		Expect(provisioner.Spec.Limits.ExceededBy(provisioner.Status.Resources)).To(MatchError("cpu resource usage of 17 exceeds limit of 16"))
 	})
+	It("should fail when the node count is higher than the node limit", func() {
+		Expect(provisioner.Spec.Limits.ExceededByNodes(100)).To(Succeed())
+		provisioner.Spec.Limits.Nodes = ptr.Int64(3)
+		Expect(provisioner.Spec.Limits.ExceededByNodes(3)).To(Succeed())
+		Expect(provisioner.Spec.Limits.ExceededByNodes(4)).To(MatchError("node count of 4 exceeds limit of 3"))
+	})
+	It("should fail when usage within a scope is higher than the scoped limit", func() {
+		scoped := ScopedLimit{
+			CapacityType: ptr.String(CapacityTypeOnDemand),
+			Resources:    v1.ResourceList{"cpu": resource.MustParse("8")},
+			Nodes:        ptr.Int64(2),
+		}
+		Expect(scoped.ExceededBy(v1.ResourceList{"cpu": resource.MustParse("8")}, 2)).To(Succeed())
+		Expect(scoped.ExceededBy(v1.ResourceList{"cpu": resource.MustParse("9")}, 1)).To(MatchError("karpenter.sh/capacity-type=on-demand cpu resource usage of 9 exceeds limit of 8"))
+		Expect(scoped.ExceededBy(v1.ResourceList{"cpu": resource.MustParse("1")}, 3)).To(MatchError("karpenter.sh/capacity-type=on-demand node count of 3 exceeds limit of 2"))
+	})
+})
+
+var _ = Describe("Disruption", func() {
+	It("should not limit disruption without budgets", func() {
+		var disruption *Disruption
+		Expect(disruption.AllowedDisruptions(10)).To(BeNumerically("==", math.MaxInt32))
+		Expect((&Disruption{}).AllowedDisruptions(10)).To(BeNumerically("==", math.MaxInt32))
+	})
+	It("should allow an absolute number of disruptions", func() {
+		disruption := &Disruption{Budgets: []Budget{{Nodes: "3"}}}
+		Expect(disruption.AllowedDisruptions(10)).To(Equal(3))
+	})
+	It("should round percentages up", func() {
+		disruption := &Disruption{Budgets: []Budget{{Nodes: "10%"}}}
+		Expect(disruption.AllowedDisruptions(11)).To(Equal(2))
+		Expect(disruption.AllowedDisruptions(1)).To(Equal(1))
+		Expect(disruption.AllowedDisruptions(0)).To(Equal(0))
+	})
+	It("should use the most restrictive budget", func() {
+		disruption := &Disruption{Budgets: []Budget{{Nodes: "50%"}, {Nodes: "2"}, {Nodes: "30%"}}}
+		Expect(disruption.AllowedDisruptions(10)).To(Equal(2))
+		Expect(disruption.AllowedDisruptions(4)).To(Equal(2))
+		Expect(disruption.AllowedDisruptions(3)).To(Equal(1))
+	})
+	It("should fail on an invalid budget", func() {
+		disruption := &Disruption{Budgets: []Budget{{Nodes: "ten"}}}
+		_, err := disruption.AllowedDisruptions(10)
+		Expect(err).To(HaveOccurred())
+	})
+	Context("Maintenance Windows", func() {
+		It("should always allow disruption without maintenance windows", func() {
+			var disruption *Disruption
+			Expect(disruption.InMaintenanceWindow(time.Now())).To(BeTrue())
+			Expect((&Disruption{}).InMaintenanceWindow(time.Now())).To(BeTrue())
+		})
+		It("should only allow disruption while a window is open", func() {
+			// weekdays from 22:00 until 06:00
+			disruption := &Disruption{MaintenanceWindows: []MaintenanceWindow{
+				{Schedule: "0 22 * * 1-5", Duration: metav1.Duration{Duration: 8 * time.Hour}},
+			}}
+			// Wednesday
+			Expect(disruption.InMaintenanceWindow(time.Date(2023, time.January, 4, 21, 59, 0, 0, time.UTC))).To(BeFalse())
+			Expect(disruption.InMaintenanceWindow(time.Date(2023, time.January, 4, 22, 0, 0, 0, time.UTC))).To(BeTrue())
+			Expect(disruption.InMaintenanceWindow(time.Date(2023, time.January, 5, 5, 59, 0, 0, time.UTC))).To(BeTrue())
+			Expect(disruption.InMaintenanceWindow(time.Date(2023, time.January, 5, 6, 0, 0, 0, time.UTC))).To(BeFalse())
+			Expect(disruption.InMaintenanceWindow(time.Date(2023, time.January, 5, 12, 0, 0, 0, time.UTC))).To(BeFalse())
+			// Sunday
+			Expect(disruption.InMaintenanceWindow(time.Date(2023, time.January, 8, 23, 0, 0, 0, time.UTC))).To(BeFalse())
+		})
+		It("should evaluate the schedule in the window's time zone", func() {
+			disruption := &Disruption{MaintenanceWindows: []MaintenanceWindow{
+				{Schedule: "0 2 * * *", TimeZone: ptr.String("America/New_York"), Duration: metav1.Duration{Duration: time.Hour}},
+			}}
+			// 02:30 in New York is 07:30 UTC in January
+			Expect(disruption.InMaintenanceWindow(time.Date(2023, time.January, 4, 7, 30, 0, 0, time.UTC))).To(BeTrue())
+			Expect(disruption.InMaintenanceWindow(time.Date(2023, time.January, 4, 2, 30, 0, 0, time.UTC))).To(BeFalse())
+		})
+		It("should allow disruption if any window is open", func() {
+			disruption := &Disruption{MaintenanceWindows: []MaintenanceWindow{
+				{Schedule: "0 1 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+				{Schedule: "0 12 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			}}
+			Expect(disruption.InMaintenanceWindow(time.Date(2023, time.January, 4, 12, 30, 0, 0, time.UTC))).To(BeTrue())
+			Expect(disruption.InMaintenanceWindow(time.Date(2023, time.January, 4, 10, 30, 0, 0, time.UTC))).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("Hash", func() {
+	var provisioner *Provisioner
+
+	BeforeEach(func() {
+		provisioner = &Provisioner{
+			ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+			Spec: ProvisionerSpec{
+				Requirements: []NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}},
+				},
+				Labels: map[string]string{"test-key": "test-value"},
+				Taints: []v1.Taint{{Key: "test-key", Effect: v1.TaintEffectNoSchedule}},
+			},
+		}
+	})
+	It("should not change when fields that aren't used to launch nodes change", func() {
+		hash := provisioner.Hash()
+		provisioner.Spec.Weight = ptr.Int32(10)
+		provisioner.Spec.TTLSecondsUntilExpired = ptr.Int64(30)
+		provisioner.Spec.Limits = &Limits{Nodes: ptr.Int64(10)}
+		provisioner.Spec.Consolidation = &Consolidation{Enabled: ptr.Bool(true)}
+		provisioner.Spec.Requirements[0].MinValues = lo.ToPtr(2)
+		Expect(provisioner.Hash()).To(Equal(hash))
+	})
+	It("should not change when the order of requirement values changes", func() {
+		hash := provisioner.Hash()
+		provisioner.Spec.Requirements[0].Values = []string{"test-zone-2", "test-zone-1"}
+		Expect(provisioner.Hash()).To(Equal(hash))
+	})
+	DescribeTable("should change when fields that are used to launch nodes change",
+		func(mutate func(*ProvisionerSpec)) {
+			hash := provisioner.Hash()
+			mutate(&provisioner.Spec)
+			Expect(provisioner.Hash()).ToNot(Equal(hash))
+		},
+		Entry("requirements", func(s *ProvisionerSpec) { s.Requirements[0].Values = []string{"test-zone-1"} }),
+		Entry("labels", func(s *ProvisionerSpec) { s.Labels["test-key"] = "other-value" }),
+		Entry("taints", func(s *ProvisionerSpec) { s.Taints[0].Effect = v1.TaintEffectNoExecute }),
+		Entry("startup taints", func(s *ProvisionerSpec) {
+			s.StartupTaints = []v1.Taint{{Key: "test-key", Effect: v1.TaintEffectNoSchedule}}
+		}),
+		Entry("kubelet configuration", func(s *ProvisionerSpec) { s.KubeletConfiguration = &KubeletConfiguration{MaxPods: ptr.Int32(10)} }),
+		Entry("provider ref", func(s *ProvisionerSpec) { s.ProviderRef = &ProviderRef{Name: "test-template"} }),
+	)
 })
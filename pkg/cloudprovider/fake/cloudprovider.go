@@ -18,8 +18,10 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/rand"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/samber/lo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,35 +39,89 @@ import (
 )
 
 var _ cloudprovider.CloudProvider = (*CloudProvider)(nil)
+var _ cloudprovider.BatchCreator = (*CloudProvider)(nil)
+var _ cloudprovider.NotificationSource = (*CloudProvider)(nil)
+var _ cloudprovider.InstanceTypesNotifier = (*CloudProvider)(nil)
 
 type CloudProvider struct {
 	InstanceTypes []*cloudprovider.InstanceType
 
-	// CreateCalls contains the arguments for every create call that was made since it was cleared
+	// CreateCalls contains the arguments for every create call that was made since it was cleared, including the calls
+	// that failed because of AllowedCreateCalls, NextCreateErr or CreateErrorRate, so that retries can be counted
 	mu                 sync.RWMutex
 	CreateCalls        []*v1alpha5.Machine
 	AllowedCreateCalls int
 	CreatedMachines    map[string]*v1alpha5.Machine
 	Drifted            bool
+	// NextCreateErr is returned by the next create call, and is then cleared
+	NextCreateErr error
+	// CreateBatchCalls contains the machines of every batch create call that was made since it was cleared
+	CreateBatchCalls [][]*v1alpha5.Machine
+	// SupportedCapabilities is returned by Capabilities. Batch create is opt-in, so that launches go through Create
+	// by default.
+	SupportedCapabilities cloudprovider.Capabilities
+	// notifications are emitted with Notify
+	notifications chan cloudprovider.Notification
+	// instanceTypesChanged is signaled when prices are updated
+	instanceTypesChanged chan struct{}
+
+	// CapacityPools limits the number of machines that can be launched into an offering, keyed by OfferingKey.
+	// Offerings without a pool have unlimited capacity, and capacity is returned to the pool when a machine is deleted.
+	CapacityPools map[string]int
+	// CreateLatency and DeleteLatency delay every create and delete call
+	CreateLatency time.Duration
+	DeleteLatency time.Duration
+	// CreateErrorRate and DeleteErrorRate are the probabilities in [0, 1] that a create or delete call fails with a
+	// retryable error
+	CreateErrorRate float64
+	DeleteErrorRate float64
+	// rand is seeded with a constant so that simulations are reproducible
+	rand               *rand.Rand
+	driftedProviderIDs sets.String
 }
 
 func NewCloudProvider() *CloudProvider {
 	return &CloudProvider{
-		AllowedCreateCalls: math.MaxInt,
-		CreatedMachines:    map[string]*v1alpha5.Machine{},
+		AllowedCreateCalls:    math.MaxInt,
+		CreatedMachines:       map[string]*v1alpha5.Machine{},
+		SupportedCapabilities: defaultCapabilities,
+		notifications:         make(chan cloudprovider.Notification, 100),
+		instanceTypesChanged:  make(chan struct{}, 1),
+		rand:                  rand.New(rand.NewSource(1)), //nolint:gosec
+		driftedProviderIDs:    sets.NewString(),
 	}
 }
 
+var defaultCapabilities = cloudprovider.Capabilities{Drift: true, Spot: true, Pricing: true, GetByName: true}
+
 // Reset is for BeforeEach calls in testing to reset the tracking of CreateCalls
 func (c *CloudProvider) Reset() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.CreateCalls = []*v1alpha5.Machine{}
+	c.CreateBatchCalls = nil
 	c.CreatedMachines = map[string]*v1alpha5.Machine{}
 	c.AllowedCreateCalls = math.MaxInt
+	c.NextCreateErr = nil
+	c.SupportedCapabilities = defaultCapabilities
+	c.CapacityPools = nil
+	c.CreateLatency, c.DeleteLatency = 0, 0
+	c.CreateErrorRate, c.DeleteErrorRate = 0, 0
+	c.rand = rand.New(rand.NewSource(1)) //nolint:gosec
+	c.driftedProviderIDs = sets.NewString()
+	for len(c.notifications) > 0 {
+		<-c.notifications
+	}
+	for len(c.instanceTypesChanged) > 0 {
+		<-c.instanceTypesChanged
+	}
 }
 
+//nolint:gocyclo
 func (c *CloudProvider) Create(ctx context.Context, machine *v1alpha5.Machine) (*v1alpha5.Machine, error) {
+	if err := sleep(ctx, c.CreateLatency); err != nil {
+		return nil, err
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -73,18 +129,38 @@ func (c *CloudProvider) Create(ctx context.Context, machine *v1alpha5.Machine) (
 	if len(c.CreateCalls) > c.AllowedCreateCalls {
 		return &v1alpha5.Machine{}, fmt.Errorf("erroring as number of AllowedCreateCalls has been exceeded")
 	}
+	if c.NextCreateErr != nil {
+		err := c.NextCreateErr
+		c.NextCreateErr = nil
+		return nil, err
+	}
+	if c.fail(c.CreateErrorRate) {
+		return nil, cloudprovider.NewRetryableError(fmt.Errorf("simulated create failure"))
+	}
 
 	reqs := scheduling.NewNodeSelectorRequirements(machine.Spec.Requirements...)
+	var exhausted *cloudprovider.InsufficientCapacityError
 	instanceTypes := lo.Filter(lo.Must(c.GetInstanceTypes(ctx, &v1alpha5.Provisioner{})), func(i *cloudprovider.InstanceType, _ int) bool {
-		return reqs.Compatible(i.Requirements) == nil &&
-			len(i.Offerings.Requirements(reqs).Available()) > 0 &&
-			resources.Fits(machine.Spec.Resources.Requests, i.Allocatable())
+		if reqs.Compatible(i.Requirements) != nil || !resources.Fits(machine.Spec.Resources.Requests, i.Allocatable()) {
+			return false
+		}
+		if len(c.launchable(i, reqs)) > 0 {
+			return true
+		}
+		if o, ok := lo.Find(i.Offerings.Available().Requirements(reqs), func(o cloudprovider.Offering) bool { return !c.hasCapacity(i, o) }); ok && exhausted == nil {
+			exhausted = cloudprovider.NewInsufficientCapacityError(i.Name, o.Zone, o.CapacityType, fmt.Errorf("simulated capacity pool is exhausted"))
+		}
+		return false
 	})
+	if len(instanceTypes) == 0 {
+		if exhausted != nil {
+			return nil, exhausted
+		}
+		return nil, fmt.Errorf("no instance types are compatible with the requirements and resource requests of machine %s", machine.Name)
+	}
 	// Order instance types so that we get the cheapest instance types of the available offerings
 	sort.Slice(instanceTypes, func(i, j int) bool {
-		iOfferings := instanceTypes[i].Offerings.Available().Requirements(reqs)
-		jOfferings := instanceTypes[j].Offerings.Available().Requirements(reqs)
-		return iOfferings.Cheapest().Price < jOfferings.Cheapest().Price
+		return c.launchable(instanceTypes[i], reqs).Cheapest().Price < c.launchable(instanceTypes[j], reqs).Cheapest().Price
 	})
 	instanceType := instanceTypes[0]
 	// Labels
@@ -95,21 +171,19 @@ func (c *CloudProvider) Create(ctx context.Context, machine *v1alpha5.Machine) (
 		}
 	}
 	// Find Offering
-	for _, o := range instanceType.Offerings.Available() {
-		if reqs.Compatible(scheduling.NewRequirements(
-			scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, o.Zone),
-			scheduling.NewRequirement(v1alpha5.LabelCapacityType, v1.NodeSelectorOpIn, o.CapacityType),
-		)) == nil {
-			labels[v1.LabelTopologyZone] = o.Zone
-			labels[v1alpha5.LabelCapacityType] = o.CapacityType
-			break
-		}
-	}
+	// Reserved offerings are launched into first, as they are already paid for
+	offerings := c.launchable(instanceType, reqs)
+	sort.SliceStable(offerings, func(i, j int) bool {
+		return offerings[i].CapacityType == v1alpha5.CapacityTypeReserved && offerings[j].CapacityType != v1alpha5.CapacityTypeReserved
+	})
+	labels = lo.Assign(labels, offerings[0].Labels())
+	c.consumeCapacity(instanceType.Name, offerings[0].Zone, offerings[0].CapacityType, -1)
 	name := test.RandomName()
 	created := &v1alpha5.Machine{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   name,
-			Labels: labels,
+			Name:              name,
+			Labels:            labels,
+			CreationTimestamp: metav1.Now(),
 		},
 		Spec: *machine.Spec.DeepCopy(),
 		Status: v1alpha5.MachineStatus{
@@ -122,6 +196,18 @@ func (c *CloudProvider) Create(ctx context.Context, machine *v1alpha5.Machine) (
 	return created, nil
 }
 
+// CreateBatch creates each of the machines, and records the batch in CreateBatchCalls
+func (c *CloudProvider) CreateBatch(ctx context.Context, machines []*v1alpha5.Machine) []cloudprovider.CreateResult {
+	c.mu.Lock()
+	c.CreateBatchCalls = append(c.CreateBatchCalls, machines)
+	c.mu.Unlock()
+
+	return lo.Map(machines, func(m *v1alpha5.Machine, _ int) cloudprovider.CreateResult {
+		created, err := c.Create(ctx, m)
+		return cloudprovider.CreateResult{Machine: created, Err: err}
+	})
+}
+
 func (c *CloudProvider) Get(_ context.Context, machineName string, _ string) (*v1alpha5.Machine, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -132,6 +218,15 @@ func (c *CloudProvider) Get(_ context.Context, machineName string, _ string) (*v
 	return nil, cloudprovider.NewMachineNotFoundError(fmt.Errorf("no machine exists with name '%s'", machineName))
 }
 
+func (c *CloudProvider) List(_ context.Context) ([]*v1alpha5.Machine, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return lo.MapToSlice(c.CreatedMachines, func(_ string, m *v1alpha5.Machine) *v1alpha5.Machine {
+		return m.DeepCopy()
+	}), nil
+}
+
 func (c *CloudProvider) GetInstanceTypes(_ context.Context, _ *v1alpha5.Provisioner) ([]*cloudprovider.InstanceType, error) {
 	if c.InstanceTypes != nil {
 		return c.InstanceTypes, nil
@@ -176,22 +271,49 @@ func (c *CloudProvider) GetInstanceTypes(_ context.Context, _ *v1alpha5.Provisio
 	}, nil
 }
 
-func (c *CloudProvider) Delete(_ context.Context, m *v1alpha5.Machine) error {
+func (c *CloudProvider) Delete(ctx context.Context, m *v1alpha5.Machine) error {
+	if err := sleep(ctx, c.DeleteLatency); err != nil {
+		return err
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if _, ok := c.CreatedMachines[m.Name]; ok {
+	if c.fail(c.DeleteErrorRate) {
+		return cloudprovider.NewRetryableError(fmt.Errorf("simulated delete failure"))
+	}
+	if created, ok := c.CreatedMachines[m.Name]; ok {
 		delete(c.CreatedMachines, m.Name)
+		c.consumeCapacity(created.Labels[v1.LabelInstanceTypeStable], created.Labels[v1.LabelTopologyZone], created.Labels[v1alpha5.LabelCapacityType], 1)
 		return nil
 	}
 	return cloudprovider.NewMachineNotFoundError(fmt.Errorf("no machine exists with name '%s'", m.Name))
 }
 
-func (c *CloudProvider) IsMachineDrifted(context.Context, *v1alpha5.Machine) (bool, error) {
-	return c.Drifted, nil
+func (c *CloudProvider) IsMachineDrifted(_ context.Context, machine *v1alpha5.Machine) (bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.Drifted || c.driftedProviderIDs.Has(machine.Status.ProviderID), nil
 }
 
 // Name returns the CloudProvider implementation name.
 func (c *CloudProvider) Name() string {
 	return "fake"
 }
+
+func (c *CloudProvider) Capabilities() cloudprovider.Capabilities {
+	return c.SupportedCapabilities
+}
+
+func (c *CloudProvider) Notifications() <-chan cloudprovider.Notification {
+	return c.notifications
+}
+
+func (c *CloudProvider) InstanceTypesChanged() <-chan struct{} {
+	return c.instanceTypesChanged
+}
+
+// Notify emits a notification, as if the instance with its provider id was going to be disrupted
+func (c *CloudProvider) Notify(notification cloudprovider.Notification) {
+	c.notifications <- notification
+}
@@ -83,6 +83,16 @@ func NewInstanceType(options InstanceTypeOptions) *cloudprovider.InstanceType {
 		scheduling.NewRequirement(ExoticInstanceLabelKey, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(IntegerInstanceLabelKey, v1.NodeSelectorOpIn, fmt.Sprint(options.Resources.Cpu().Value())),
 	)
+	// offerings may be constrained by more than their zone and capacity type, which the instance type has to offer
+	for _, o := range options.Offerings.Available() {
+		for key, requirement := range o.Requirements {
+			if requirements.Has(key) {
+				requirements.Get(key).Insert(requirement.Values()...)
+			} else {
+				requirements.Add(scheduling.NewRequirement(key, v1.NodeSelectorOpIn, requirement.Values()...))
+			}
+		}
+	}
 	if options.Resources.Cpu().Cmp(resource.MustParse("4")) > 0 &&
 		options.Resources.Memory().Cmp(resource.MustParse("8Gi")) > 0 {
 		requirements.Get(LabelInstanceSize).Insert("large")
@@ -0,0 +1,150 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/scheduling"
+)
+
+// This file contains the parts of the fake cloud provider that simulate how a real cloud provider behaves: capacity
+// pools that run out, latency, random failures, spot interruptions, drift and prices that change over time. None of
+// them are enabled by default, so that tests that don't use them keep getting instant and successful calls.
+
+// OfferingKey is the key of the capacity pool of an offering in CapacityPools
+func OfferingKey(instanceType, zone, capacityType string) string {
+	return fmt.Sprintf("%s/%s/%s", instanceType, zone, capacityType)
+}
+
+// SetDrifted sets whether the machine with the provider id is drifted, independently of Drifted
+func (c *CloudProvider) SetDrifted(providerID string, drifted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if drifted {
+		c.driftedProviderIDs.Insert(providerID)
+	} else {
+		c.driftedProviderIDs.Delete(providerID)
+	}
+}
+
+// InterruptSpot notifies that each of the spot machines is going to be interrupted with the given probability. The
+// interruptions are emitted as notifications with a deadline of the notice after now, and the provider ids of the
+// interrupted machines are returned.
+func (c *CloudProvider) InterruptSpot(probability float64, notice time.Duration) []string {
+	c.mu.Lock()
+	var interrupted []string
+	for _, m := range c.CreatedMachines {
+		if m.Labels[v1alpha5.LabelCapacityType] == v1alpha5.CapacityTypeSpot && c.fail(probability) {
+			interrupted = append(interrupted, m.Status.ProviderID)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, providerID := range interrupted {
+		c.Notify(cloudprovider.Notification{
+			Kind:       cloudprovider.Interruption,
+			ProviderID: providerID,
+			Deadline:   time.Now().Add(notice),
+			Message:    "Simulated spot interruption",
+		})
+	}
+	return interrupted
+}
+
+// UpdatePrices sets the price of every offering to the price returned by the function, and signals that the instance
+// types changed
+func (c *CloudProvider) UpdatePrices(price func(*cloudprovider.InstanceType, cloudprovider.Offering) float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.signalInstanceTypesChanged()
+
+	// the default instance types are created on every call, so they have to be kept for the new prices to stick
+	if c.InstanceTypes == nil {
+		c.InstanceTypes = lo.Must(c.GetInstanceTypes(context.Background(), &v1alpha5.Provisioner{}))
+	}
+	for _, it := range c.InstanceTypes {
+		for i := range it.Offerings {
+			it.Offerings[i].Price = price(it, it.Offerings[i])
+		}
+	}
+}
+
+// FluctuateSpotPrices changes the price of every spot offering by a random factor of up to the volatility, e.g. up to
+// 10% up or down for a volatility of 0.1. Calling it repeatedly makes spot prices walk over time.
+func (c *CloudProvider) FluctuateSpotPrices(volatility float64) {
+	c.UpdatePrices(func(_ *cloudprovider.InstanceType, o cloudprovider.Offering) float64 {
+		if o.CapacityType != v1alpha5.CapacityTypeSpot {
+			return o.Price
+		}
+		// rand is guarded by the lock that UpdatePrices holds
+		return math.Max(0, o.Price*(1+volatility*(2*c.rand.Float64()-1)))
+	})
+}
+
+// signalInstanceTypesChanged signals that the instance types changed, unless a signal is already pending
+func (c *CloudProvider) signalInstanceTypesChanged() {
+	select {
+	case c.instanceTypesChanged <- struct{}{}:
+	default:
+	}
+}
+
+// launchable returns the offerings of the instance type that are compatible with the requirements and that have
+// capacity left
+func (c *CloudProvider) launchable(it *cloudprovider.InstanceType, reqs scheduling.Requirements) cloudprovider.Offerings {
+	return lo.Filter(it.Offerings.Available().Requirements(reqs), func(o cloudprovider.Offering, _ int) bool {
+		return c.hasCapacity(it, o)
+	})
+}
+
+func (c *CloudProvider) hasCapacity(it *cloudprovider.InstanceType, o cloudprovider.Offering) bool {
+	remaining, ok := c.CapacityPools[OfferingKey(it.Name, o.Zone, o.CapacityType)]
+	return !ok || remaining > 0
+}
+
+// consumeCapacity adds the delta to the capacity pool of the offering, if it has one
+func (c *CloudProvider) consumeCapacity(instanceType, zone, capacityType string, delta int) {
+	key := OfferingKey(instanceType, zone, capacityType)
+	if _, ok := c.CapacityPools[key]; ok {
+		c.CapacityPools[key] += delta
+	}
+}
+
+// fail returns true with the given probability. It must be called with the lock held.
+func (c *CloudProvider) fail(probability float64) bool {
+	return probability > 0 && c.rand.Float64() < probability
+}
+
+// sleep waits for the latency, or until the context is done
+func sleep(ctx context.Context, latency time.Duration) error {
+	if latency <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(latency):
+		return nil
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+)
+
+var ctx context.Context
+var cloudProvider *fake.CloudProvider
+
+func TestFake(t *testing.T) {
+	ctx = context.Background()
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CloudProvider/Fake")
+}
+
+var _ = BeforeEach(func() {
+	cloudProvider = fake.NewCloudProvider()
+	cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+		fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name: "instance-type",
+			Offerings: []cloudprovider.Offering{
+				{CapacityType: v1alpha5.CapacityTypeSpot, Zone: "test-zone-1", Price: 1, Available: true},
+				{CapacityType: v1alpha5.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 2, Available: true},
+			},
+		}),
+	}
+})
+
+func machine(name string, capacityTypes ...string) *v1alpha5.Machine {
+	return &v1alpha5.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1alpha5.MachineSpec{
+			Requirements: []v1.NodeSelectorRequirement{{Key: v1alpha5.LabelCapacityType, Operator: v1.NodeSelectorOpIn, Values: capacityTypes}},
+		},
+	}
+}
+
+var _ = Describe("Simulator", func() {
+	It("should launch into other offerings once a capacity pool is exhausted", func() {
+		cloudProvider.CapacityPools = map[string]int{fake.OfferingKey("instance-type", "test-zone-1", v1alpha5.CapacityTypeSpot): 1}
+		spot := lo.Must(cloudProvider.Create(ctx, machine("first", v1alpha5.CapacityTypeSpot, v1alpha5.CapacityTypeOnDemand)))
+		Expect(spot.Labels).To(HaveKeyWithValue(v1alpha5.LabelCapacityType, v1alpha5.CapacityTypeSpot))
+		onDemand := lo.Must(cloudProvider.Create(ctx, machine("second", v1alpha5.CapacityTypeSpot, v1alpha5.CapacityTypeOnDemand)))
+		Expect(onDemand.Labels).To(HaveKeyWithValue(v1alpha5.LabelCapacityType, v1alpha5.CapacityTypeOnDemand))
+	})
+	It("should return insufficient capacity errors when capacity pools are exhausted, until capacity is returned", func() {
+		cloudProvider.CapacityPools = map[string]int{fake.OfferingKey("instance-type", "test-zone-1", v1alpha5.CapacityTypeSpot): 1}
+		_, err := cloudProvider.Create(ctx, machine("first", v1alpha5.CapacityTypeSpot))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = cloudProvider.Create(ctx, machine("second", v1alpha5.CapacityTypeSpot))
+		Expect(cloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+
+		Expect(cloudProvider.Delete(ctx, machine("first"))).To(Succeed())
+		_, err = cloudProvider.Create(ctx, machine("second", v1alpha5.CapacityTypeSpot))
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should fail calls with retryable errors at the error rate", func() {
+		cloudProvider.CreateErrorRate = 1
+		_, err := cloudProvider.Create(ctx, machine("first", v1alpha5.CapacityTypeSpot))
+		Expect(cloudprovider.IsRetryableError(err)).To(BeTrue())
+		// failed calls are recorded too
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+
+		cloudProvider.CreateErrorRate = 0
+		cloudProvider.DeleteErrorRate = 1
+		lo.Must(cloudProvider.Create(ctx, machine("first", v1alpha5.CapacityTypeSpot)))
+		Expect(cloudprovider.IsRetryableError(cloudProvider.Delete(ctx, machine("first")))).To(BeTrue())
+		Expect(cloudProvider.CreatedMachines).To(HaveKey("first"))
+	})
+	It("should fail to create machines that no instance type is compatible with", func() {
+		_, err := cloudProvider.Create(ctx, machine("first", v1alpha5.CapacityTypeReserved))
+		Expect(err).To(HaveOccurred())
+		Expect(cloudprovider.IsInsufficientCapacityError(err)).To(BeFalse())
+		Expect(cloudProvider.CreatedMachines).To(BeEmpty())
+	})
+	It("should delay calls by the latency", func() {
+		cloudProvider.CreateLatency = time.Minute
+		timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, err := cloudProvider.Create(timeoutCtx, machine("first", v1alpha5.CapacityTypeSpot))
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(cloudProvider.CreateCalls).To(BeEmpty())
+	})
+	It("should notify that spot machines are going to be interrupted", func() {
+		spot := lo.Must(cloudProvider.Create(ctx, machine("spot", v1alpha5.CapacityTypeSpot)))
+		lo.Must(cloudProvider.Create(ctx, machine("on-demand", v1alpha5.CapacityTypeOnDemand)))
+		Expect(cloudProvider.InterruptSpot(1, 2*time.Minute)).To(ConsistOf(spot.Status.ProviderID))
+
+		var notification cloudprovider.Notification
+		Eventually(cloudProvider.Notifications()).Should(Receive(&notification))
+		Expect(notification.Kind).To(Equal(cloudprovider.Interruption))
+		Expect(notification.ProviderID).To(Equal(spot.Status.ProviderID))
+		Expect(notification.Deadline).To(BeTemporally(">", time.Now()))
+		Consistently(cloudProvider.Notifications()).ShouldNot(Receive())
+	})
+	It("should drift individual machines", func() {
+		first := lo.Must(cloudProvider.Create(ctx, machine("first", v1alpha5.CapacityTypeSpot)))
+		second := lo.Must(cloudProvider.Create(ctx, machine("second", v1alpha5.CapacityTypeSpot)))
+		cloudProvider.SetDrifted(first.Status.ProviderID, true)
+		Expect(cloudProvider.IsMachineDrifted(ctx, first)).To(BeTrue())
+		Expect(cloudProvider.IsMachineDrifted(ctx, second)).To(BeFalse())
+		cloudProvider.SetDrifted(first.Status.ProviderID, false)
+		Expect(cloudProvider.IsMachineDrifted(ctx, first)).To(BeFalse())
+	})
+	It("should change prices over time", func() {
+		cloudProvider.FluctuateSpotPrices(0.5)
+		offerings := lo.Must(cloudProvider.GetInstanceTypes(ctx, &v1alpha5.Provisioner{}))[0].Offerings
+		Expect(offerings[0].Price).ToNot(Equal(1.0))
+		Expect(offerings[0].Price).To(BeNumerically("~", 1.0, 0.5))
+		Expect(offerings[1].Price).To(Equal(2.0))
+		Expect(cloudProvider.InstanceTypesChanged()).To(Receive())
+
+		cloudProvider.UpdatePrices(func(_ *cloudprovider.InstanceType, o cloudprovider.Offering) float64 { return o.Price * 2 })
+		offerings = lo.Must(cloudProvider.GetInstanceTypes(ctx, &v1alpha5.Provisioner{}))[0].Offerings
+		Expect(offerings[1].Price).To(Equal(4.0))
+	})
+})
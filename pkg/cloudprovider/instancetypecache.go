@@ -0,0 +1,202 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/metrics"
+)
+
+// InstanceTypesTTL is how long the instance types of a provisioner are cached for
+const InstanceTypesTTL = 5 * time.Minute
+
+var instanceTypeCacheCounterVec = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cloudprovider",
+		Name:      "instance_type_cache_requests",
+		Help:      "Number of instance type requests served by the instance type cache. Labeled by whether the request was a hit or a miss.",
+	},
+	[]string{"result"},
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(instanceTypeCacheCounterVec)
+}
+
+// InstanceTypesNotifier is an optional extension of CloudProvider for cloud providers that know when their instance
+// types change, e.g. when the prices or the availability of offerings are refreshed, or when the objects that
+// provisioners reference with their provider ref change. Cached instance types are invalidated and consolidation is
+// re-evaluated whenever the channel receives.
+type InstanceTypesNotifier interface {
+	InstanceTypesChanged() <-chan struct{}
+}
+
+// InstanceTypesChanged returns the instance type changes of the cloud provider, or nil if it isn't an
+// InstanceTypesNotifier
+func InstanceTypesChanged(cloudProvider CloudProvider) <-chan struct{} {
+	if notifier, ok := cloudProvider.(InstanceTypesNotifier); ok {
+		return notifier.InstanceTypesChanged()
+	}
+	return nil
+}
+
+// InstanceTypeCache caches the instance types of provisioners, keyed by the provisioner name and the hash of its spec,
+// so that the controllers that need them don't all call the cloud provider. Changes to the object that the provider
+// ref of a provisioner points to are picked up once the TTL expires, or right away if the cloud provider signals them
+// as an InstanceTypesNotifier.
+type InstanceTypeCache struct {
+	mu       sync.RWMutex
+	clock    clock.Clock
+	entries  map[string]instanceTypeCacheEntry
+	onChange []func()
+}
+
+type instanceTypeCacheEntry struct {
+	instanceTypes []*InstanceType
+	expires       time.Time
+}
+
+func NewInstanceTypeCache(clk clock.Clock) *InstanceTypeCache {
+	return &InstanceTypeCache{
+		clock:   clk,
+		entries: map[string]instanceTypeCacheEntry{},
+	}
+}
+
+// Invalidate removes the cached instance types of the provisioner
+func (c *InstanceTypeCache) Invalidate(provisionerName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, provisionerName+"/") {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// InvalidateAll removes the cached instance types of every provisioner
+func (c *InstanceTypeCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]instanceTypeCacheEntry{}
+}
+
+// OnChange registers a function that is called after the cache is invalidated because the cloud provider signaled that
+// its instance types changed
+func (c *InstanceTypeCache) OnChange(f func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onChange = append(c.onChange, f)
+}
+
+// Watch invalidates the whole cache and calls the functions registered with OnChange whenever the cloud provider
+// signals on the channel that its instance types changed, until the context is done
+func (c *InstanceTypeCache) Watch(ctx context.Context, changed <-chan struct{}) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-changed:
+				if !ok {
+					return
+				}
+				logging.FromContext(ctx).Debugf("invalidating instance types, cloud provider signaled a change")
+				c.InvalidateAll()
+				c.mu.RLock()
+				onChange := c.onChange
+				c.mu.RUnlock()
+				for _, f := range onChange {
+					f()
+				}
+			}
+		}
+	}()
+}
+
+func (c *InstanceTypeCache) get(key string) ([]*InstanceType, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.clock.Now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.instanceTypes, true
+}
+
+func (c *InstanceTypeCache) set(key string, instanceTypes []*InstanceType) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = instanceTypeCacheEntry{instanceTypes: instanceTypes, expires: c.clock.Now().Add(InstanceTypesTTL)}
+}
+
+// key identifies the instance types of a provisioner. Instance types depend on the launch-relevant fields of the
+// provisioner, including its provider ref and the deprecated provider field which isn't part of the provisioner hash.
+func (c *InstanceTypeCache) key(provisioner *v1alpha5.Provisioner) string {
+	var raw []byte
+	if provisioner.Spec.Provider != nil {
+		raw = provisioner.Spec.Provider.Raw
+	}
+	return fmt.Sprintf("%s/%s/%d", provisioner.Name, provisioner.Hash(), lo.Must(hashstructure.Hash(raw, hashstructure.FormatV2, nil)))
+}
+
+type instanceTypeCacheDecorator struct {
+	CloudProvider
+	Forwarder
+	cache *InstanceTypeCache
+}
+
+// DecorateWithInstanceTypeCache returns a CloudProvider that caches the instance types that it returns for each
+// provisioner until the TTL expires or the cache is invalidated
+func DecorateWithInstanceTypeCache(cloudProvider CloudProvider, cache *InstanceTypeCache) CloudProvider {
+	return &instanceTypeCacheDecorator{CloudProvider: cloudProvider, Forwarder: Forwarder{CloudProvider: cloudProvider}, cache: cache}
+}
+
+func (d *instanceTypeCacheDecorator) GetInstanceTypes(ctx context.Context, provisioner *v1alpha5.Provisioner) ([]*InstanceType, error) {
+	if provisioner == nil {
+		return d.CloudProvider.GetInstanceTypes(ctx, provisioner)
+	}
+	key := d.cache.key(provisioner)
+	if instanceTypes, ok := d.cache.get(key); ok {
+		instanceTypeCacheCounterVec.WithLabelValues("hit").Inc()
+		// callers are free to reorder or filter the slice, but not the instance types themselves
+		return append([]*InstanceType{}, instanceTypes...), nil
+	}
+	instanceTypeCacheCounterVec.WithLabelValues("miss").Inc()
+	instanceTypes, err := d.CloudProvider.GetInstanceTypes(ctx, provisioner)
+	if err != nil {
+		return nil, err
+	}
+	d.cache.set(key, instanceTypes)
+	return append([]*InstanceType{}, instanceTypes...), nil
+}
+
+func (d *instanceTypeCacheDecorator) CreateBatch(ctx context.Context, machines []*v1alpha5.Machine) []CreateResult {
+	return CreateBatch(ctx, d.CloudProvider, machines)
+}
@@ -53,17 +53,19 @@ func init() {
 
 type decorator struct {
 	cloudprovider.CloudProvider
+	cloudprovider.Forwarder
 }
 
 // Decorate returns a new `CloudProvider` instance that will delegate all method
 // calls to the argument, `cloudProvider`, and publish aggregated latency metrics. The
 // value used for the metric label, "controller", is taken from the `Context` object
-// passed to the methods of `CloudProvider`.
+// passed to the methods of `CloudProvider`. Notifications and instance type changes are
+// forwarded, and are nil channels if the argument doesn't send them.
 //
 // Do not decorate a `CloudProvider` multiple times or published metrics will contain
 // duplicated method call counts and latencies.
 func Decorate(cloudProvider cloudprovider.CloudProvider) cloudprovider.CloudProvider {
-	return &decorator{cloudProvider}
+	return &decorator{CloudProvider: cloudProvider, Forwarder: cloudprovider.Forwarder{CloudProvider: cloudProvider}}
 }
 
 func (d *decorator) Create(ctx context.Context, machine *v1alpha5.Machine) (*v1alpha5.Machine, error) {
@@ -71,6 +73,11 @@ func (d *decorator) Create(ctx context.Context, machine *v1alpha5.Machine) (*v1a
 	return d.CloudProvider.Create(ctx, machine)
 }
 
+func (d *decorator) CreateBatch(ctx context.Context, machines []*v1alpha5.Machine) []cloudprovider.CreateResult {
+	defer metrics.Measure(methodDurationHistogramVec.WithLabelValues(injection.GetControllerName(ctx), "CreateBatch", d.Name()))()
+	return cloudprovider.CreateBatch(ctx, d.CloudProvider, machines)
+}
+
 func (d *decorator) Delete(ctx context.Context, machine *v1alpha5.Machine) error {
 	defer metrics.Measure(methodDurationHistogramVec.WithLabelValues(injection.GetControllerName(ctx), "Delete", d.Name()))()
 	return d.CloudProvider.Delete(ctx, machine)
@@ -81,6 +88,11 @@ func (d *decorator) Get(ctx context.Context, machineName, provisionerName string
 	return d.CloudProvider.Get(ctx, machineName, provisionerName)
 }
 
+func (d *decorator) List(ctx context.Context) ([]*v1alpha5.Machine, error) {
+	defer metrics.Measure(methodDurationHistogramVec.WithLabelValues(injection.GetControllerName(ctx), "List", d.Name()))()
+	return d.CloudProvider.List(ctx)
+}
+
 func (d *decorator) GetInstanceTypes(ctx context.Context, provisioner *v1alpha5.Provisioner) ([]*cloudprovider.InstanceType, error) {
 	defer metrics.Measure(methodDurationHistogramVec.WithLabelValues(injection.GetControllerName(ctx), "GetInstanceTypes", d.Name()))()
 	return d.CloudProvider.GetInstanceTypes(ctx, provisioner)
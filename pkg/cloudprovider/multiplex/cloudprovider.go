@@ -0,0 +1,269 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multiplex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/metrics"
+)
+
+var _ cloudprovider.CloudProvider = (*CloudProvider)(nil)
+var _ cloudprovider.NotificationSource = (*CloudProvider)(nil)
+var _ cloudprovider.InstanceTypesNotifier = (*CloudProvider)(nil)
+
+// CloudProvider routes each call to one of several cloud providers, chosen by the group and kind of the provider ref
+// of the provisioner, or of the machine template ref of the machine. Machines without a ref, e.g. machines of nodes,
+// are routed by the provider ref of their provisioner. Provisioners without a provider ref are routed to the default
+// cloud provider, if there is one.
+//
+// Each cloud provider is decorated with metrics when it's registered, so that the metrics are labeled by the actual
+// backend. Don't decorate the multiplexer itself with metrics.
+type CloudProvider struct {
+	kubeClient      client.Client
+	defaultProvider cloudprovider.CloudProvider
+	providers       map[schema.GroupKind]cloudprovider.CloudProvider
+
+	notificationsOnce        sync.Once
+	notifications            chan cloudprovider.Notification
+	instanceTypesChangedOnce sync.Once
+	instanceTypesChanged     chan struct{}
+}
+
+// NewCloudProvider returns a multiplexer that routes calls for provisioners without a provider ref to the default
+// cloud provider, which may be nil if every provisioner has a provider ref
+func NewCloudProvider(kubeClient client.Client, defaultProvider cloudprovider.CloudProvider) *CloudProvider {
+	c := &CloudProvider{
+		kubeClient:           kubeClient,
+		providers:            map[schema.GroupKind]cloudprovider.CloudProvider{},
+		notifications:        make(chan cloudprovider.Notification, 100),
+		instanceTypesChanged: make(chan struct{}, 1),
+	}
+	if defaultProvider != nil {
+		c.defaultProvider = metrics.Decorate(defaultProvider)
+	}
+	return c
+}
+
+// Register routes the calls for provider refs of the group and kind to the cloud provider. It must be called before
+// the multiplexer is used.
+func (c *CloudProvider) Register(groupKind schema.GroupKind, cloudProvider cloudprovider.CloudProvider) *CloudProvider {
+	c.providers[groupKind] = metrics.Decorate(cloudProvider)
+	return c
+}
+
+func (c *CloudProvider) Create(ctx context.Context, machine *v1alpha5.Machine) (*v1alpha5.Machine, error) {
+	cloudProvider, err := c.forMachine(ctx, machine)
+	if err != nil {
+		return nil, err
+	}
+	return cloudProvider.Create(ctx, machine)
+}
+
+func (c *CloudProvider) Delete(ctx context.Context, machine *v1alpha5.Machine) error {
+	cloudProvider, err := c.forMachine(ctx, machine)
+	if err != nil {
+		return err
+	}
+	return cloudProvider.Delete(ctx, machine)
+}
+
+// Get retrieves the machine from the cloud provider of its provisioner, or from every cloud provider if the
+// provisioner isn't known
+func (c *CloudProvider) Get(ctx context.Context, machineName string, provisionerName string) (*v1alpha5.Machine, error) {
+	if provisionerName != "" {
+		cloudProvider, err := c.forProvisionerName(ctx, provisionerName)
+		if err != nil {
+			return nil, err
+		}
+		return cloudProvider.Get(ctx, machineName, provisionerName)
+	}
+	for _, cloudProvider := range c.all() {
+		machine, err := cloudProvider.Get(ctx, machineName, provisionerName)
+		if cloudprovider.IgnoreMachineNotFoundError(err) != nil {
+			return nil, err
+		}
+		if err == nil {
+			return machine, nil
+		}
+	}
+	return nil, cloudprovider.NewMachineNotFoundError(fmt.Errorf("no cloud provider has a machine with name '%s'", machineName))
+}
+
+// List returns the machines of every cloud provider
+func (c *CloudProvider) List(ctx context.Context) ([]*v1alpha5.Machine, error) {
+	var machines []*v1alpha5.Machine
+	var errs error
+	for _, cloudProvider := range c.all() {
+		listed, err := cloudProvider.List(ctx)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("listing machines of %s, %w", cloudProvider.Name(), err))
+			continue
+		}
+		machines = append(machines, listed...)
+	}
+	return machines, errs
+}
+
+func (c *CloudProvider) GetInstanceTypes(ctx context.Context, provisioner *v1alpha5.Provisioner) ([]*cloudprovider.InstanceType, error) {
+	var ref *v1alpha5.ProviderRef
+	if provisioner != nil {
+		ref = provisioner.Spec.ProviderRef
+	}
+	cloudProvider, err := c.forRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	return cloudProvider.GetInstanceTypes(ctx, provisioner)
+}
+
+func (c *CloudProvider) IsMachineDrifted(ctx context.Context, machine *v1alpha5.Machine) (bool, error) {
+	cloudProvider, err := c.forMachine(ctx, machine)
+	if err != nil {
+		return false, err
+	}
+	// the multiplexer detects drift if any of its cloud providers does, so the others have to be skipped here
+	if !cloudProvider.Capabilities().Drift {
+		return false, nil
+	}
+	return cloudProvider.IsMachineDrifted(ctx, machine)
+}
+
+// Name returns the names of the cloud providers. Use the names of the cloud providers that the calls are routed to,
+// e.g. in metrics, rather than this one.
+func (c *CloudProvider) Name() string {
+	names := lo.Map(c.all(), func(cp cloudprovider.CloudProvider, _ int) string { return cp.Name() })
+	sort.Strings(names)
+	return strings.Join(lo.Uniq(names), ",")
+}
+
+// Capabilities returns the capabilities that all the cloud providers have, except for drift which only has to be
+// supported by one of them since drift detection is routed per machine. Spot has to be supported by all of them, as
+// consolidation can't tell which cloud provider a replacement would be launched by. Calls aren't batched, as the
+// machines of a batch may belong to different cloud providers.
+func (c *CloudProvider) Capabilities() cloudprovider.Capabilities {
+	all := c.all()
+	if len(all) == 0 {
+		return cloudprovider.Capabilities{}
+	}
+	capabilities := cloudprovider.Capabilities{Spot: true, Pricing: true, GetByName: true}
+	for _, cp := range all {
+		cpCapabilities := cp.Capabilities()
+		capabilities.Drift = capabilities.Drift || cpCapabilities.Drift
+		capabilities.Spot = capabilities.Spot && cpCapabilities.Spot
+		capabilities.Pricing = capabilities.Pricing && cpCapabilities.Pricing
+		capabilities.GetByName = capabilities.GetByName && cpCapabilities.GetByName
+	}
+	return capabilities
+}
+
+// Notifications merges the notifications of the cloud providers that are notification sources
+func (c *CloudProvider) Notifications() <-chan cloudprovider.Notification {
+	c.notificationsOnce.Do(func() {
+		for _, cp := range c.all() {
+			if notifications := cloudprovider.Notifications(cp); notifications != nil {
+				go func(notifications <-chan cloudprovider.Notification) {
+					for notification := range notifications {
+						c.notifications <- notification
+					}
+				}(notifications)
+			}
+		}
+	})
+	return c.notifications
+}
+
+// InstanceTypesChanged merges the signals of the cloud providers that are instance types notifiers
+func (c *CloudProvider) InstanceTypesChanged() <-chan struct{} {
+	c.instanceTypesChangedOnce.Do(func() {
+		for _, cp := range c.all() {
+			if changed := cloudprovider.InstanceTypesChanged(cp); changed != nil {
+				go func(changed <-chan struct{}) {
+					for range changed {
+						// signals are coalesced, as a pending signal invalidates all instance types anyway
+						select {
+						case c.instanceTypesChanged <- struct{}{}:
+						default:
+						}
+					}
+				}(changed)
+			}
+		}
+	})
+	return c.instanceTypesChanged
+}
+
+func (c *CloudProvider) forMachine(ctx context.Context, machine *v1alpha5.Machine) (cloudprovider.CloudProvider, error) {
+	if machine.Spec.MachineTemplateRef != nil {
+		return c.forRef(machine.Spec.MachineTemplateRef)
+	}
+	if provisionerName, ok := machine.Labels[v1alpha5.ProvisionerNameLabelKey]; ok {
+		return c.forProvisionerName(ctx, provisionerName)
+	}
+	return c.forRef(nil)
+}
+
+func (c *CloudProvider) forProvisionerName(ctx context.Context, provisionerName string) (cloudprovider.CloudProvider, error) {
+	provisioner := &v1alpha5.Provisioner{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: provisionerName}, provisioner); err != nil {
+		// the provisioner may have been deleted before its machines, which are routed to the default cloud provider
+		if errors.IsNotFound(err) && c.defaultProvider != nil {
+			return c.defaultProvider, nil
+		}
+		return nil, fmt.Errorf("getting provisioner, %w", err)
+	}
+	return c.forRef(provisioner.Spec.ProviderRef)
+}
+
+func (c *CloudProvider) forRef(ref *v1alpha5.ProviderRef) (cloudprovider.CloudProvider, error) {
+	if ref == nil {
+		if c.defaultProvider == nil {
+			return nil, fmt.Errorf("no provider ref and no default cloud provider")
+		}
+		return c.defaultProvider, nil
+	}
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("parsing provider ref api version, %w", err)
+	}
+	cloudProvider, ok := c.providers[gv.WithKind(ref.Kind).GroupKind()]
+	if !ok {
+		return nil, fmt.Errorf("no cloud provider is registered for %s", gv.WithKind(ref.Kind).GroupKind())
+	}
+	return cloudProvider, nil
+}
+
+// all returns the default cloud provider and the registered ones, in a stable order
+func (c *CloudProvider) all() []cloudprovider.CloudProvider {
+	keys := lo.Keys(c.providers)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	all := lo.Map(keys, func(k schema.GroupKind, _ int) cloudprovider.CloudProvider { return c.providers[k] })
+	if c.defaultProvider != nil {
+		all = append([]cloudprovider.CloudProvider{c.defaultProvider}, all...)
+	}
+	return all
+}
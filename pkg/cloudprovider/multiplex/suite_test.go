@@ -0,0 +1,161 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multiplex_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	. "knative.dev/pkg/logging/testing"
+
+	"github.com/aws/karpenter-core/pkg/apis"
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/multiplex"
+	"github.com/aws/karpenter-core/pkg/operator/scheme"
+	"github.com/aws/karpenter-core/pkg/test"
+	. "github.com/aws/karpenter-core/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *test.Environment
+var defaultCloudProvider *fake.CloudProvider
+var onPremCloudProvider *fake.CloudProvider
+var cloudProvider *multiplex.CloudProvider
+var onPremRef *v1alpha5.ProviderRef
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CloudProvider/Multiplex")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(scheme.Scheme, test.WithCRDs(apis.CRDs...))
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	defaultCloudProvider = fake.NewCloudProvider()
+	defaultCloudProvider.InstanceTypes = []*cloudprovider.InstanceType{fake.NewInstanceType(fake.InstanceTypeOptions{Name: "cloud-instance-type"})}
+	onPremCloudProvider = fake.NewCloudProvider()
+	onPremCloudProvider.InstanceTypes = []*cloudprovider.InstanceType{fake.NewInstanceType(fake.InstanceTypeOptions{Name: "on-prem-instance-type"})}
+	onPremCloudProvider.SupportedCapabilities = cloudprovider.Capabilities{Pricing: true, GetByName: true}
+	cloudProvider = multiplex.NewCloudProvider(env.Client, defaultCloudProvider).
+		Register(schema.GroupKind{Group: "onprem.test.com", Kind: "Rack"}, onPremCloudProvider)
+	onPremRef = &v1alpha5.ProviderRef{APIVersion: "onprem.test.com/v1", Kind: "Rack", Name: "default"}
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Multiplex", func() {
+	It("should route instance types by the provider ref of the provisioner", func() {
+		provisioner := test.Provisioner()
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, provisioner)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes[0].Name).To(Equal("cloud-instance-type"))
+
+		provisioner.Spec.ProviderRef = onPremRef
+		instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, provisioner)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes[0].Name).To(Equal("on-prem-instance-type"))
+
+		// any version of the group is routed to the same cloud provider
+		provisioner.Spec.ProviderRef = &v1alpha5.ProviderRef{APIVersion: "onprem.test.com/v2", Kind: "Rack", Name: "default"}
+		instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, provisioner)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes[0].Name).To(Equal("on-prem-instance-type"))
+	})
+	It("should fail for provider refs that no cloud provider is registered for", func() {
+		provisioner := test.Provisioner()
+		provisioner.Spec.ProviderRef = &v1alpha5.ProviderRef{APIVersion: "other.test.com/v1", Kind: "Rack", Name: "default"}
+		_, err := cloudProvider.GetInstanceTypes(ctx, provisioner)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should route creates by the machine template ref of the machine", func() {
+		_, err := cloudProvider.Create(ctx, test.Machine(v1alpha5.Machine{Spec: v1alpha5.MachineSpec{MachineTemplateRef: onPremRef}}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(onPremCloudProvider.CreateCalls).To(HaveLen(1))
+		Expect(defaultCloudProvider.CreateCalls).To(BeEmpty())
+
+		_, err = cloudProvider.Create(ctx, test.Machine())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(defaultCloudProvider.CreateCalls).To(HaveLen(1))
+	})
+	It("should route machines without a ref by the provider ref of their provisioner", func() {
+		provisioner := test.Provisioner(test.ProvisionerOptions{ProviderRef: onPremRef})
+		ExpectApplied(ctx, env.Client, provisioner)
+		machine := test.Machine(v1alpha5.Machine{ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name},
+		}})
+		_, err := cloudProvider.Create(ctx, machine)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(onPremCloudProvider.CreatedMachines).To(HaveKey(machine.Name))
+
+		onPremCloudProvider.Drifted = true
+		defaultCloudProvider.Drifted = true
+		// the on-prem cloud provider doesn't detect drift
+		Expect(cloudProvider.IsMachineDrifted(ctx, machine)).To(BeFalse())
+		_, err = cloudProvider.Get(ctx, machine.Name, provisioner.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cloudProvider.Delete(ctx, machine)).To(Succeed())
+		Expect(onPremCloudProvider.CreatedMachines).To(BeEmpty())
+	})
+	It("should list and get machines from every cloud provider", func() {
+		onPrem := test.Machine(v1alpha5.Machine{Spec: v1alpha5.MachineSpec{MachineTemplateRef: onPremRef}})
+		cloud := test.Machine()
+		lo.Must(cloudProvider.Create(ctx, onPrem))
+		lo.Must(cloudProvider.Create(ctx, cloud))
+
+		machines, err := cloudProvider.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machines).To(HaveLen(2))
+		_, err = cloudProvider.Get(ctx, onPrem.Name, "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = cloudProvider.Get(ctx, "missing", "")
+		Expect(cloudprovider.IsMachineNotFoundError(err)).To(BeTrue())
+	})
+	It("should combine the capabilities of the cloud providers", func() {
+		Expect(cloudProvider.Capabilities()).To(Equal(cloudprovider.Capabilities{Drift: true, Pricing: true, GetByName: true}))
+		onPremCloudProvider.SupportedCapabilities.Spot = true
+		Expect(cloudProvider.Capabilities().Spot).To(BeTrue())
+		onPremCloudProvider.SupportedCapabilities.GetByName = false
+		Expect(cloudProvider.Capabilities().GetByName).To(BeFalse())
+		Expect(cloudProvider.Name()).To(Equal("fake"))
+	})
+	It("should merge the notifications of the cloud providers", func() {
+		notifications := cloudProvider.Notifications()
+		onPremCloudProvider.Notify(cloudprovider.Notification{Kind: cloudprovider.Interruption, ProviderID: "on-prem"})
+		defaultCloudProvider.Notify(cloudprovider.Notification{Kind: cloudprovider.Interruption, ProviderID: "cloud"})
+		var received []string
+		Eventually(func(g Gomega) {
+			var notification cloudprovider.Notification
+			g.Expect(notifications).To(Receive(&notification))
+			received = append(received, notification.ProviderID)
+			g.Expect(received).To(ConsistOf("on-prem", "cloud"))
+		}).Should(Succeed())
+	})
+})
@@ -0,0 +1,78 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"fmt"
+	"time"
+)
+
+// NotificationKind is the kind of disruption that a cloud provider notifies about
+type NotificationKind string
+
+const (
+	// Interruption notifies that the instance is going to be reclaimed, e.g. a spot interruption
+	Interruption NotificationKind = "Interruption"
+	// ScheduledMaintenance notifies that the instance is going to be stopped or retired at the deadline
+	ScheduledMaintenance NotificationKind = "ScheduledMaintenance"
+	// RebalanceRecommendation notifies that the instance is at an elevated risk of being interrupted
+	RebalanceRecommendation NotificationKind = "RebalanceRecommendation"
+)
+
+// Notification is a notice from the cloud provider that the instance with the provider id is going to be disrupted
+type Notification struct {
+	Kind       NotificationKind
+	ProviderID string
+	// Deadline is when the instance is going to be disrupted, if the cloud provider knows it
+	Deadline time.Time
+	Message  string
+}
+
+func (n Notification) String() string {
+	if n.Deadline.IsZero() {
+		return fmt.Sprintf("%s for %s", n.Kind, n.ProviderID)
+	}
+	return fmt.Sprintf("%s for %s at %s", n.Kind, n.ProviderID, n.Deadline.Format(time.RFC3339))
+}
+
+// NotificationSource is an optional extension of CloudProvider for cloud providers that are notified when their
+// instances are going to be interrupted, maintained or should be rebalanced. The nodes of the instances are cordoned,
+// replaced and drained ahead of the deadline.
+type NotificationSource interface {
+	Notifications() <-chan Notification
+}
+
+// Notifications returns the notifications of the cloud provider, or nil if it isn't a NotificationSource
+func Notifications(cloudProvider CloudProvider) <-chan Notification {
+	if source, ok := cloudProvider.(NotificationSource); ok {
+		return source.Notifications()
+	}
+	return nil
+}
+
+// Forwarder forwards the notifications and the instance type changes of a cloud provider. Decorators embed it along
+// with the cloud provider that they decorate, so that the cloud provider stays a NotificationSource and an
+// InstanceTypesNotifier when it's decorated.
+type Forwarder struct {
+	CloudProvider CloudProvider
+}
+
+func (f Forwarder) Notifications() <-chan Notification {
+	return Notifications(f.CloudProvider)
+}
+
+func (f Forwarder) InstanceTypesChanged() <-chan struct{} {
+	return InstanceTypesChanged(f.CloudProvider)
+}
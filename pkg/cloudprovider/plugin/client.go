@@ -0,0 +1,175 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+)
+
+var _ cloudprovider.CloudProvider = (*Client)(nil)
+var _ cloudprovider.BatchCreator = (*Client)(nil)
+
+// discoveryTimeout bounds the calls that retrieve the name and capabilities of the plugin server
+const discoveryTimeout = 10 * time.Second
+
+// Client is a cloudprovider.CloudProvider that forwards every call to a plugin server
+type Client struct {
+	endpoint   string
+	httpClient *http.Client
+
+	name         string
+	capabilities cloudprovider.Capabilities
+}
+
+// NewClient returns a cloud provider that calls the plugin server at the endpoint, e.g. http://localhost:8080. The
+// name and capabilities of the cloud provider behind the server are retrieved once here, as the interface doesn't
+// allow for an error when they're used, and they're used by the controllers on every pass.
+func NewClient(ctx context.Context, endpoint string, httpClient *http.Client) (*Client, error) {
+	c := &Client{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: httpClient,
+	}
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+	name := &NameResponse{}
+	if err := c.call(ctx, NamePath, &Empty{}, name); err != nil {
+		return nil, fmt.Errorf("getting name of plugin server, %w", err)
+	}
+	capabilities := &CapabilitiesResponse{}
+	if err := c.call(ctx, CapabilitiesPath, &Empty{}, capabilities); err != nil {
+		return nil, fmt.Errorf("getting capabilities of plugin server, %w", err)
+	}
+	c.name = name.Name
+	c.capabilities = cloudprovider.Capabilities{
+		Drift:       capabilities.Drift,
+		Spot:        capabilities.Spot,
+		Pricing:     capabilities.Pricing,
+		BatchCreate: capabilities.BatchCreate,
+		GetByName:   capabilities.GetByName,
+	}
+	return c, nil
+}
+
+func (c *Client) Create(ctx context.Context, machine *v1alpha5.Machine) (*v1alpha5.Machine, error) {
+	resp := &MachineResponse{}
+	if err := c.call(ctx, CreatePath, &MachineRequest{Machine: machine}, resp); err != nil {
+		return nil, err
+	}
+	return resp.Machine, nil
+}
+
+// CreateBatch launches the machines with a single call to the plugin server. The server falls back to creating each
+// machine individually if its cloud provider doesn't support batch create.
+func (c *Client) CreateBatch(ctx context.Context, machines []*v1alpha5.Machine) []cloudprovider.CreateResult {
+	resp := &CreateBatchResponse{}
+	if err := c.call(ctx, CreateBatchPath, &CreateBatchRequest{Machines: machines}, resp); err != nil {
+		return lo.Map(machines, func(_ *v1alpha5.Machine, _ int) cloudprovider.CreateResult {
+			return cloudprovider.CreateResult{Err: err}
+		})
+	}
+	return lo.Map(resp.Results, func(r CreateResult, _ int) cloudprovider.CreateResult {
+		if r.Error != nil {
+			return cloudprovider.CreateResult{Err: r.Error.Err()}
+		}
+		return cloudprovider.CreateResult{Machine: r.Machine}
+	})
+}
+
+func (c *Client) Delete(ctx context.Context, machine *v1alpha5.Machine) error {
+	return c.call(ctx, DeletePath, &MachineRequest{Machine: machine}, &Empty{})
+}
+
+func (c *Client) Get(ctx context.Context, machineName, provisionerName string) (*v1alpha5.Machine, error) {
+	resp := &MachineResponse{}
+	if err := c.call(ctx, GetPath, &GetRequest{MachineName: machineName, ProvisionerName: provisionerName}, resp); err != nil {
+		return nil, err
+	}
+	return resp.Machine, nil
+}
+
+func (c *Client) List(ctx context.Context) ([]*v1alpha5.Machine, error) {
+	resp := &ListResponse{}
+	if err := c.call(ctx, ListPath, &Empty{}, resp); err != nil {
+		return nil, err
+	}
+	return resp.Machines, nil
+}
+
+func (c *Client) GetInstanceTypes(ctx context.Context, provisioner *v1alpha5.Provisioner) ([]*cloudprovider.InstanceType, error) {
+	resp := &GetInstanceTypesResponse{}
+	if err := c.call(ctx, GetInstanceTypesPath, &GetInstanceTypesRequest{Provisioner: provisioner}, resp); err != nil {
+		return nil, err
+	}
+	return lo.Map(resp.InstanceTypes, func(i InstanceType, _ int) *cloudprovider.InstanceType {
+		return i.InstanceType()
+	}), nil
+}
+
+func (c *Client) IsMachineDrifted(ctx context.Context, machine *v1alpha5.Machine) (bool, error) {
+	resp := &IsMachineDriftedResponse{}
+	if err := c.call(ctx, IsMachineDriftedPath, &MachineRequest{Machine: machine}, resp); err != nil {
+		return false, err
+	}
+	return resp.Drifted, nil
+}
+
+// Name returns the name of the cloud provider behind the plugin server
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Capabilities returns the capabilities of the cloud provider behind the plugin server
+func (c *Client) Capabilities() cloudprovider.Capabilities {
+	return c.capabilities
+}
+
+func (c *Client) call(ctx context.Context, path string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("marshaling request, %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request, %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling %s, %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		e := &Error{}
+		if err := json.NewDecoder(resp.Body).Decode(e); err != nil {
+			return fmt.Errorf("calling %s, status %d", path, resp.StatusCode)
+		}
+		return e.Err()
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response of %s, %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plugin defines an HTTP/JSON protocol that mirrors the cloudprovider.CloudProvider interface, so that a cloud
+// provider can run out of process (e.g. as a sidecar) instead of being compiled into the controller. Every call is a
+// POST of a JSON request to its path, answered with a JSON response, or with an Error and a non-200 status code.
+package plugin
+
+import (
+	"errors"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/scheduling"
+)
+
+const (
+	CreatePath           = "/v1/create"
+	CreateBatchPath      = "/v1/createbatch"
+	DeletePath           = "/v1/delete"
+	GetPath              = "/v1/get"
+	ListPath             = "/v1/list"
+	GetInstanceTypesPath = "/v1/instancetypes"
+	IsMachineDriftedPath = "/v1/drifted"
+	NamePath             = "/v1/name"
+	CapabilitiesPath     = "/v1/capabilities"
+)
+
+// ErrorType identifies the cloudprovider errors that callers act on, so that they survive the trip over the wire
+type ErrorType string
+
+const (
+	ErrorTypeMachineNotFound      ErrorType = "MachineNotFound"
+	ErrorTypeInsufficientCapacity ErrorType = "InsufficientCapacity"
+	ErrorTypeRetryable            ErrorType = "Retryable"
+)
+
+type MachineRequest struct {
+	Machine *v1alpha5.Machine `json:"machine"`
+}
+
+type MachineResponse struct {
+	Machine *v1alpha5.Machine `json:"machine"`
+}
+
+type CreateBatchRequest struct {
+	Machines []*v1alpha5.Machine `json:"machines"`
+}
+
+type CreateBatchResponse struct {
+	Results []CreateResult `json:"results"`
+}
+
+// CreateResult is the result of launching a single machine of a batch, either the machine or the error is set
+type CreateResult struct {
+	Machine *v1alpha5.Machine `json:"machine,omitempty"`
+	Error   *Error            `json:"error,omitempty"`
+}
+
+type GetRequest struct {
+	MachineName     string `json:"machineName"`
+	ProvisionerName string `json:"provisionerName"`
+}
+
+type ListResponse struct {
+	Machines []*v1alpha5.Machine `json:"machines"`
+}
+
+type GetInstanceTypesRequest struct {
+	Provisioner *v1alpha5.Provisioner `json:"provisioner"`
+}
+
+type GetInstanceTypesResponse struct {
+	InstanceTypes []InstanceType `json:"instanceTypes"`
+}
+
+type IsMachineDriftedResponse struct {
+	Drifted bool `json:"drifted"`
+}
+
+type NameResponse struct {
+	Name string `json:"name"`
+}
+
+type CapabilitiesResponse struct {
+	Drift       bool `json:"drift"`
+	Spot        bool `json:"spot"`
+	Pricing     bool `json:"pricing"`
+	BatchCreate bool `json:"batchCreate"`
+	GetByName   bool `json:"getByName"`
+}
+
+// Empty is the response of calls that don't return anything
+type Empty struct{}
+
+// Error is returned with a non-200 status code when a call fails
+type Error struct {
+	Type    ErrorType `json:"type,omitempty"`
+	Message string    `json:"message"`
+	// InstanceType, Zone and CapacityType identify the offering of an InsufficientCapacity error
+	InstanceType string `json:"instanceType,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	CapacityType string `json:"capacityType,omitempty"`
+}
+
+// InstanceType is the wire representation of a cloudprovider.InstanceType
+type InstanceType struct {
+	Name         string                       `json:"name"`
+	Requirements []v1.NodeSelectorRequirement `json:"requirements,omitempty"`
+	Offerings    []Offering                   `json:"offerings,omitempty"`
+	Capacity     v1.ResourceList              `json:"capacity,omitempty"`
+	Overhead     InstanceTypeOverhead         `json:"overhead"`
+}
+
+type InstanceTypeOverhead struct {
+	KubeReserved      v1.ResourceList `json:"kubeReserved,omitempty"`
+	SystemReserved    v1.ResourceList `json:"systemReserved,omitempty"`
+	EvictionThreshold v1.ResourceList `json:"evictionThreshold,omitempty"`
+}
+
+type Offering struct {
+	CapacityType string                       `json:"capacityType"`
+	Zone         string                       `json:"zone"`
+	Requirements []v1.NodeSelectorRequirement `json:"requirements,omitempty"`
+	Price        float64                      `json:"price"`
+	// ReservationCapacity is the number of nodes that can still be launched into a reserved offering
+	ReservationCapacity int  `json:"reservationCapacity,omitempty"`
+	Available           bool `json:"available"`
+}
+
+// NewError converts an error returned by a cloud provider to its wire representation. The message of a typed error is
+// the message of the error that it wraps, since the type is wrapped around it again on the other side.
+func NewError(err error) *Error {
+	var iceErr *cloudprovider.InsufficientCapacityError
+	if errors.As(err, &iceErr) {
+		return &Error{
+			Type:         ErrorTypeInsufficientCapacity,
+			Message:      message(iceErr.Err),
+			InstanceType: iceErr.InstanceType,
+			Zone:         iceErr.Zone,
+			CapacityType: iceErr.CapacityType,
+		}
+	}
+	var mnfErr *cloudprovider.MachineNotFoundError
+	if errors.As(err, &mnfErr) {
+		return &Error{Type: ErrorTypeMachineNotFound, Message: message(mnfErr.Err)}
+	}
+	var retryableErr *cloudprovider.RetryableError
+	if errors.As(err, &retryableErr) {
+		return &Error{Type: ErrorTypeRetryable, Message: message(retryableErr.Err)}
+	}
+	return &Error{Message: err.Error()}
+}
+
+func message(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Err converts the wire representation of an error back to the error returned by the cloud provider
+func (e *Error) Err() error {
+	err := errors.New(e.Message)
+	switch e.Type {
+	case ErrorTypeMachineNotFound:
+		return cloudprovider.NewMachineNotFoundError(err)
+	case ErrorTypeInsufficientCapacity:
+		return cloudprovider.NewInsufficientCapacityError(e.InstanceType, e.Zone, e.CapacityType, err)
+	case ErrorTypeRetryable:
+		return cloudprovider.NewRetryableError(err)
+	default:
+		return err
+	}
+}
+
+// NewInstanceType converts an instance type to its wire representation
+func NewInstanceType(it *cloudprovider.InstanceType) InstanceType {
+	out := InstanceType{
+		Name:         it.Name,
+		Requirements: it.Requirements.NodeSelectorRequirements(),
+		Offerings: lo.Map(it.Offerings, func(o cloudprovider.Offering, _ int) Offering {
+			return Offering{
+				CapacityType:        o.CapacityType,
+				Zone:                o.Zone,
+				Requirements:        o.Requirements.NodeSelectorRequirements(),
+				Price:               o.Price,
+				ReservationCapacity: o.ReservationCapacity,
+				Available:           o.Available,
+			}
+		}),
+		Capacity: it.Capacity,
+	}
+	if it.Overhead != nil {
+		out.Overhead = InstanceTypeOverhead{
+			KubeReserved:      it.Overhead.KubeReserved,
+			SystemReserved:    it.Overhead.SystemReserved,
+			EvictionThreshold: it.Overhead.EvictionThreshold,
+		}
+	}
+	return out
+}
+
+// InstanceType converts the wire representation of an instance type back to a cloudprovider.InstanceType
+func (i InstanceType) InstanceType() *cloudprovider.InstanceType {
+	return &cloudprovider.InstanceType{
+		Name:         i.Name,
+		Requirements: scheduling.NewNodeSelectorRequirements(i.Requirements...),
+		Offerings: lo.Map(i.Offerings, func(o Offering, _ int) cloudprovider.Offering {
+			return cloudprovider.Offering{
+				CapacityType:        o.CapacityType,
+				Zone:                o.Zone,
+				Requirements:        scheduling.NewNodeSelectorRequirements(o.Requirements...),
+				Price:               o.Price,
+				ReservationCapacity: o.ReservationCapacity,
+				Available:           o.Available,
+			}
+		}),
+		Capacity: i.Capacity,
+		Overhead: &cloudprovider.InstanceTypeOverhead{
+			KubeReserved:      i.Overhead.KubeReserved,
+			SystemReserved:    i.Overhead.SystemReserved,
+			EvictionThreshold: i.Overhead.EvictionThreshold,
+		},
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+)
+
+// Server serves the plugin protocol for a cloud provider. Providers that run out of process wrap their implementation
+// of cloudprovider.CloudProvider with it, and the controller calls them through a Client.
+type Server struct {
+	cloudProvider cloudprovider.CloudProvider
+	mux           *http.ServeMux
+}
+
+func NewServer(cloudProvider cloudprovider.CloudProvider) *Server {
+	s := &Server{cloudProvider: cloudProvider, mux: http.NewServeMux()}
+	handle(s.mux, CreatePath, func(ctx context.Context, req *MachineRequest) (*MachineResponse, error) {
+		machine, err := s.cloudProvider.Create(ctx, req.Machine)
+		return &MachineResponse{Machine: machine}, err
+	})
+	handle(s.mux, CreateBatchPath, func(ctx context.Context, req *CreateBatchRequest) (*CreateBatchResponse, error) {
+		return &CreateBatchResponse{Results: lo.Map(cloudprovider.CreateBatch(ctx, s.cloudProvider, req.Machines), func(r cloudprovider.CreateResult, _ int) CreateResult {
+			if r.Err != nil {
+				return CreateResult{Error: NewError(r.Err)}
+			}
+			return CreateResult{Machine: r.Machine}
+		})}, nil
+	})
+	handle(s.mux, DeletePath, func(ctx context.Context, req *MachineRequest) (*Empty, error) {
+		return &Empty{}, s.cloudProvider.Delete(ctx, req.Machine)
+	})
+	handle(s.mux, GetPath, func(ctx context.Context, req *GetRequest) (*MachineResponse, error) {
+		machine, err := s.cloudProvider.Get(ctx, req.MachineName, req.ProvisionerName)
+		return &MachineResponse{Machine: machine}, err
+	})
+	handle(s.mux, ListPath, func(ctx context.Context, _ *Empty) (*ListResponse, error) {
+		machines, err := s.cloudProvider.List(ctx)
+		return &ListResponse{Machines: machines}, err
+	})
+	handle(s.mux, GetInstanceTypesPath, func(ctx context.Context, req *GetInstanceTypesRequest) (*GetInstanceTypesResponse, error) {
+		instanceTypes, err := s.cloudProvider.GetInstanceTypes(ctx, req.Provisioner)
+		return &GetInstanceTypesResponse{InstanceTypes: lo.Map(instanceTypes, func(i *cloudprovider.InstanceType, _ int) InstanceType {
+			return NewInstanceType(i)
+		})}, err
+	})
+	handle(s.mux, IsMachineDriftedPath, func(ctx context.Context, req *MachineRequest) (*IsMachineDriftedResponse, error) {
+		drifted, err := s.cloudProvider.IsMachineDrifted(ctx, req.Machine)
+		return &IsMachineDriftedResponse{Drifted: drifted}, err
+	})
+	handle(s.mux, NamePath, func(_ context.Context, _ *Empty) (*NameResponse, error) {
+		return &NameResponse{Name: s.cloudProvider.Name()}, nil
+	})
+	handle(s.mux, CapabilitiesPath, func(_ context.Context, _ *Empty) (*CapabilitiesResponse, error) {
+		capabilities := s.cloudProvider.Capabilities()
+		return &CapabilitiesResponse{
+			Drift:       capabilities.Drift,
+			Spot:        capabilities.Spot,
+			Pricing:     capabilities.Pricing,
+			BatchCreate: capabilities.BatchCreate,
+			GetByName:   capabilities.GetByName,
+		}, nil
+	})
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func handle[I, O any](mux *http.ServeMux, path string, f func(context.Context, *I) (*O, error)) {
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			write(w, http.StatusMethodNotAllowed, &Error{Message: fmt.Sprintf("method %s is not allowed", r.Method)})
+			return
+		}
+		in := new(I)
+		if err := json.NewDecoder(r.Body).Decode(in); err != nil {
+			write(w, http.StatusBadRequest, &Error{Message: fmt.Sprintf("decoding request, %s", err)})
+			return
+		}
+		out, err := f(r.Context(), in)
+		if err != nil {
+			write(w, http.StatusInternalServerError, NewError(err))
+			return
+		}
+		write(w, http.StatusOK, out)
+	})
+}
+
+func write(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
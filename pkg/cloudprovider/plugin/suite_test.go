@@ -0,0 +1,189 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/plugin"
+	"github.com/aws/karpenter-core/pkg/scheduling"
+	"github.com/aws/karpenter-core/pkg/utils/resources"
+)
+
+var ctx context.Context
+var fakeCloudProvider *fake.CloudProvider
+var server *httptest.Server
+var client *plugin.Client
+
+func TestPlugin(t *testing.T) {
+	ctx = context.Background()
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CloudProvider/Plugin")
+}
+
+var _ = BeforeEach(func() {
+	fakeCloudProvider = fake.NewCloudProvider()
+	server = fake.NewPluginServer(fakeCloudProvider)
+	var err error
+	client, err = plugin.NewClient(ctx, server.URL, server.Client())
+	Expect(err).ToNot(HaveOccurred())
+})
+
+var _ = AfterEach(func() {
+	server.Close()
+})
+
+var _ = Describe("Plugin", func() {
+	var machine *v1alpha5.Machine
+	BeforeEach(func() {
+		machine = &v1alpha5.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: "machine"},
+			Spec: v1alpha5.MachineSpec{
+				Resources: v1alpha5.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}},
+			},
+		}
+	})
+	It("should create, get, list and delete machines", func() {
+		created, err := client.Create(ctx, machine)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created.Status.ProviderID).ToNot(BeEmpty())
+		Expect(fakeCloudProvider.CreateCalls).To(HaveLen(1))
+
+		retrieved, err := client.Get(ctx, machine.Name, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(retrieved.Status.ProviderID).To(Equal(created.Status.ProviderID))
+
+		machines, err := client.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machines).To(HaveLen(1))
+		Expect(machines[0].Status.ProviderID).To(Equal(created.Status.ProviderID))
+
+		Expect(client.Delete(ctx, machine)).To(Succeed())
+		Expect(fakeCloudProvider.CreatedMachines).To(BeEmpty())
+	})
+	It("should create machines in a batch and report errors per machine", func() {
+		fakeCloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError("default-instance-type", "test-zone-1", v1alpha5.CapacityTypeSpot, errors.New("no capacity"))
+		other := machine.DeepCopy()
+		other.Name = "other-machine"
+		results := client.CreateBatch(ctx, []*v1alpha5.Machine{machine, other})
+		Expect(results).To(HaveLen(2))
+		Expect(cloudprovider.IsInsufficientCapacityError(results[0].Err)).To(BeTrue())
+		Expect(results[1].Err).ToNot(HaveOccurred())
+		Expect(results[1].Machine.Status.ProviderID).ToNot(BeEmpty())
+		Expect(fakeCloudProvider.CreatedMachines).To(HaveKey(other.Name))
+	})
+	It("should return instance types", func() {
+		expected := lo.Must(fakeCloudProvider.GetInstanceTypes(ctx, &v1alpha5.Provisioner{}))
+		instanceTypes, err := client.GetInstanceTypes(ctx, &v1alpha5.Provisioner{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(len(expected)))
+		for i := range expected {
+			Expect(instanceTypes[i].Name).To(Equal(expected[i].Name))
+			Expect(instanceTypes[i].Offerings).To(HaveLen(len(expected[i].Offerings)))
+			for j, o := range expected[i].Offerings {
+				Expect(instanceTypes[i].Offerings[j].Zone).To(Equal(o.Zone))
+				Expect(instanceTypes[i].Offerings[j].CapacityType).To(Equal(o.CapacityType))
+				Expect(instanceTypes[i].Offerings[j].Available).To(Equal(o.Available))
+				Expect(instanceTypes[i].Offerings[j].Price).To(BeNumerically("~", o.Price, 1e-9))
+			}
+			Expect(instanceTypes[i].Requirements.Compatible(expected[i].Requirements)).To(Succeed())
+			Expect(instanceTypes[i].Requirements.Keys().Equal(expected[i].Requirements.Keys())).To(BeTrue())
+			Expect(resources.Fits(instanceTypes[i].Allocatable(), expected[i].Allocatable())).To(BeTrue())
+			Expect(resources.Fits(expected[i].Allocatable(), instanceTypes[i].Allocatable())).To(BeTrue())
+		}
+	})
+	It("should return the requirements of offerings", func() {
+		fakeCloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "reserved",
+				Offerings: []cloudprovider.Offering{{
+					CapacityType: v1alpha5.CapacityTypeOnDemand,
+					Zone:         "test-zone-1",
+					Requirements: scheduling.NewRequirements(scheduling.NewRequirement("test.com/reservation-id", v1.NodeSelectorOpIn, "r-1")),
+					Price:        1.00,
+					Available:    true,
+				}},
+			}),
+		}
+		instanceTypes, err := client.GetInstanceTypes(ctx, &v1alpha5.Provisioner{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].Offerings).To(HaveLen(1))
+		Expect(instanceTypes[0].Offerings[0].Labels()).To(HaveKeyWithValue("test.com/reservation-id", "r-1"))
+		Expect(instanceTypes[0].Offerings[0].Compatible(scheduling.NewLabelRequirements(map[string]string{"test.com/reservation-id": "r-2"}))).To(BeFalse())
+		Expect(instanceTypes[0].Requirements.Get("test.com/reservation-id").Has("r-1")).To(BeTrue())
+	})
+	It("should return drift and the cloud provider name", func() {
+		fakeCloudProvider.Drifted = true
+		drifted, err := client.IsMachineDrifted(ctx, machine)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drifted).To(BeTrue())
+		Expect(client.Name()).To(Equal("fake"))
+	})
+	It("should return the capabilities of the cloud provider", func() {
+		fakeCloudProvider.SupportedCapabilities = cloudprovider.Capabilities{Drift: true, GetByName: true}
+		client, err := plugin.NewClient(ctx, server.URL, server.Client())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client.Capabilities()).To(Equal(cloudprovider.Capabilities{Drift: true, GetByName: true}))
+	})
+	It("should fail to create a client if the plugin server can't be reached", func() {
+		server.Close()
+		_, err := plugin.NewClient(ctx, server.URL, server.Client())
+		Expect(err).To(HaveOccurred())
+	})
+	It("should preserve machine not found errors", func() {
+		_, err := client.Get(ctx, "missing", "")
+		Expect(cloudprovider.IsMachineNotFoundError(err)).To(BeTrue())
+		Expect(cloudprovider.IsMachineNotFoundError(client.Delete(ctx, machine))).To(BeTrue())
+	})
+	It("should preserve insufficient capacity errors", func() {
+		fakeCloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError("default-instance-type", "test-zone-1", v1alpha5.CapacityTypeSpot, errors.New("no capacity"))
+		_, err := client.Create(ctx, machine)
+		Expect(cloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+		iceErr := &cloudprovider.InsufficientCapacityError{}
+		Expect(errors.As(err, &iceErr)).To(BeTrue())
+		Expect(iceErr.InstanceType).To(Equal("default-instance-type"))
+		Expect(iceErr.Zone).To(Equal("test-zone-1"))
+		Expect(iceErr.CapacityType).To(Equal(v1alpha5.CapacityTypeSpot))
+		Expect(iceErr.Err).To(MatchError("no capacity"))
+	})
+	It("should preserve retryable errors", func() {
+		fakeCloudProvider.NextCreateErr = cloudprovider.NewRetryableError(errors.New("throttled"))
+		_, err := client.Create(ctx, machine)
+		Expect(cloudprovider.IsRetryableError(err)).To(BeTrue())
+		Expect(errors.Unwrap(err)).To(MatchError("throttled"))
+	})
+	It("should return other errors", func() {
+		fakeCloudProvider.NextCreateErr = errors.New("failed")
+		_, err := client.Create(ctx, machine)
+		Expect(err).To(MatchError("failed"))
+		Expect(cloudprovider.IsInsufficientCapacityError(err)).To(BeFalse())
+		Expect(cloudprovider.IsMachineNotFoundError(err)).To(BeFalse())
+		Expect(cloudprovider.IsRetryableError(err)).To(BeFalse())
+	})
+})
@@ -0,0 +1,176 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/metrics"
+)
+
+var circuitOpenGaugeVec = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cloudprovider",
+		Name:      "circuit_breaker_open",
+		Help:      "Whether the circuit breaker for creating machines is open. Labeled by the provisioner.",
+	},
+	[]string{metrics.ProvisionerLabel},
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(circuitOpenGaugeVec)
+}
+
+// CircuitOpenError is returned instead of calling the cloud provider while the circuit of a provisioner is open
+type CircuitOpenError struct {
+	ProvisionerName string
+	Until           time.Time
+	Err             error
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for provisioner %s is open until %s, %s", e.ProvisionerName, e.Until.Format(time.RFC3339), e.Err)
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return e.Err
+}
+
+func IsCircuitOpenError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var coErr *CircuitOpenError
+	return errors.As(err, &coErr)
+}
+
+// CircuitBreaker tracks consecutive failures to create machines per provisioner. Once the threshold is reached, the
+// circuit opens and creates are rejected without calling the cloud provider until the cooldown elapses. A single
+// create is then let through: the circuit closes if it succeeds and opens again if it fails.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	clock     clock.Clock
+	threshold int
+	cooldown  time.Duration
+	circuits  map[string]*circuit
+}
+
+type circuit struct {
+	failures int
+	openedAt time.Time
+	probing  bool
+	lastErr  error
+}
+
+func NewCircuitBreaker(clk clock.Clock, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		clock:     clk,
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  map[string]*circuit{},
+	}
+}
+
+// Allow returns a CircuitOpenError if creates for the provisioner should be rejected
+func (b *CircuitBreaker) Allow(provisionerName string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[provisionerName]
+	if !ok || !b.tripped(c) {
+		return nil
+	}
+	until := c.openedAt.Add(b.cooldown)
+	if c.probing || b.clock.Now().Before(until) {
+		return &CircuitOpenError{ProvisionerName: provisionerName, Until: until, Err: c.lastErr}
+	}
+	c.probing = true
+	return nil
+}
+
+// Record updates the circuit of the provisioner with the result of a create. Only failures that show that the cloud
+// provider is unavailable count towards opening the circuit. Failures that are caused by the machine or the offering,
+// like insufficient capacity or an invalid launch, are answers of a cloud provider that is up.
+func (b *CircuitBreaker) Record(ctx context.Context, provisionerName string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[provisionerName]
+	if err != nil && !isUnavailableError(err) {
+		// let another create through if this one was probing the cloud provider
+		if ok && !IsCircuitOpenError(err) {
+			c.probing = false
+		}
+		return
+	}
+	if err == nil {
+		if ok && b.tripped(c) {
+			logging.FromContext(ctx).With("provisioner", provisionerName).Infof("closing circuit breaker, created machine")
+			circuitOpenGaugeVec.WithLabelValues(provisionerName).Set(0)
+		}
+		delete(b.circuits, provisionerName)
+		return
+	}
+	if !ok {
+		c = &circuit{}
+		b.circuits[provisionerName] = c
+	}
+	c.failures++
+	c.lastErr = err
+	c.probing = false
+	if b.tripped(c) {
+		if c.failures == b.threshold {
+			logging.FromContext(ctx).With("provisioner", provisionerName, "failures", c.failures, "cooldown", b.cooldown).
+				Errorf("opening circuit breaker, %s", err)
+			circuitOpenGaugeVec.WithLabelValues(provisionerName).Set(1)
+		}
+		c.openedAt = b.clock.Now()
+	}
+}
+
+// Open returns the CircuitOpenError of the provisioner if its circuit is open or waiting on a create to close it, and
+// nil otherwise
+func (b *CircuitBreaker) Open(provisionerName string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[provisionerName]
+	if !ok || !b.tripped(c) {
+		return nil
+	}
+	return &CircuitOpenError{ProvisionerName: provisionerName, Until: c.openedAt.Add(b.cooldown), Err: c.lastErr}
+}
+
+func (b *CircuitBreaker) tripped(c *circuit) bool {
+	return b.threshold > 0 && c.failures >= b.threshold
+}
+
+// isUnavailableError returns true if the error is transient or failed to reach the cloud provider
+func isUnavailableError(err error) bool {
+	if IsCircuitOpenError(err) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	return cloudprovider.IsRetryableError(err) || errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,198 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/samber/lo"
+	"golang.org/x/time/rate"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+)
+
+// Method names that rate limits are configured for, matching the methods of cloudprovider.CloudProvider
+const (
+	MethodCreate           = "Create"
+	MethodCreateBatch      = "CreateBatch"
+	MethodDelete           = "Delete"
+	MethodGet              = "Get"
+	MethodList             = "List"
+	MethodGetInstanceTypes = "GetInstanceTypes"
+	MethodIsMachineDrifted = "IsMachineDrifted"
+)
+
+// RateLimit is a token bucket that calls to a method wait on
+type RateLimit struct {
+	QPS   float64
+	Burst int
+}
+
+// Options configure the decorator returned by Decorate
+type Options struct {
+	// RateLimits limits the rate of calls per method. Methods without a rate limit are not limited.
+	RateLimits map[string]RateLimit
+	// Retries is the number of times a call that failed with a cloudprovider.RetryableError is retried
+	Retries uint
+	// RetryDelay is the delay before the first retry, which doubles with every retry up to MaxRetryDelay
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+}
+
+// DefaultOptions retries retryable errors a few times within seconds, and doesn't limit the rate of calls
+func DefaultOptions() Options {
+	return Options{
+		Retries:       3,
+		RetryDelay:    500 * time.Millisecond,
+		MaxRetryDelay: 5 * time.Second,
+	}
+}
+
+type decorator struct {
+	cloudprovider.CloudProvider
+	cloudprovider.Forwarder
+	options        Options
+	limiters       map[string]*rate.Limiter
+	circuitBreaker *CircuitBreaker
+}
+
+// Decorate returns a new `CloudProvider` instance that waits on the rate limit of each method before delegating
+// calls to the argument, `cloudProvider`, and retries calls that fail with a `cloudprovider.RetryableError` with
+// backoff. Creates are counted by the circuit breaker, which rejects creates for a provisioner without calling the
+// cloud provider after repeated failures. Notifications and instance type changes are forwarded, and are nil channels
+// if the argument doesn't send them.
+//
+// Decorate the `CloudProvider` before any decorator that reacts to the errors that it returns, so that they see the
+// result of the retries rather than every attempt.
+func Decorate(cloudProvider cloudprovider.CloudProvider, options Options, circuitBreaker *CircuitBreaker) cloudprovider.CloudProvider {
+	return &decorator{
+		CloudProvider: cloudProvider,
+		Forwarder:     cloudprovider.Forwarder{CloudProvider: cloudProvider},
+		options:       options,
+		limiters: lo.MapValues(options.RateLimits, func(r RateLimit, _ string) *rate.Limiter {
+			return rate.NewLimiter(rate.Limit(r.QPS), r.Burst)
+		}),
+		circuitBreaker: circuitBreaker,
+	}
+}
+
+func (d *decorator) Create(ctx context.Context, machine *v1alpha5.Machine) (*v1alpha5.Machine, error) {
+	provisionerName := machine.Labels[v1alpha5.ProvisionerNameLabelKey]
+	if err := d.circuitBreaker.Allow(provisionerName); err != nil {
+		return nil, err
+	}
+	var created *v1alpha5.Machine
+	err := d.do(ctx, MethodCreate, func() (err error) {
+		created, err = d.CloudProvider.Create(ctx, machine)
+		return err
+	})
+	d.circuitBreaker.Record(ctx, provisionerName, err)
+	return created, err
+}
+
+// CreateBatch creates the machines whose circuit is closed in batches, retrying the machines that failed with a
+// retryable error in a smaller batch
+func (d *decorator) CreateBatch(ctx context.Context, machines []*v1alpha5.Machine) []cloudprovider.CreateResult {
+	results := make([]cloudprovider.CreateResult, len(machines))
+	var pending []int
+	for i, machine := range machines {
+		if err := d.circuitBreaker.Allow(machine.Labels[v1alpha5.ProvisionerNameLabelKey]); err != nil {
+			results[i] = cloudprovider.CreateResult{Err: err}
+			continue
+		}
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		return results
+	}
+	// errors are reported per machine in the results
+	_ = d.do(ctx, MethodCreateBatch, func() error {
+		batch := cloudprovider.CreateBatch(ctx, d.CloudProvider, lo.Map(pending, func(i int, _ int) *v1alpha5.Machine { return machines[i] }))
+		for j, i := range pending {
+			results[i] = batch[j]
+		}
+		pending = lo.Filter(pending, func(i int, _ int) bool { return cloudprovider.IsRetryableError(results[i].Err) })
+		if len(pending) > 0 {
+			return results[pending[0]].Err
+		}
+		return nil
+	})
+	for i, machine := range machines {
+		d.circuitBreaker.Record(ctx, machine.Labels[v1alpha5.ProvisionerNameLabelKey], results[i].Err)
+	}
+	return results
+}
+
+func (d *decorator) Delete(ctx context.Context, machine *v1alpha5.Machine) error {
+	return d.do(ctx, MethodDelete, func() error {
+		return d.CloudProvider.Delete(ctx, machine)
+	})
+}
+
+func (d *decorator) Get(ctx context.Context, machineName, provisionerName string) (machine *v1alpha5.Machine, err error) {
+	err = d.do(ctx, MethodGet, func() error {
+		machine, err = d.CloudProvider.Get(ctx, machineName, provisionerName)
+		return err
+	})
+	return machine, err
+}
+
+func (d *decorator) List(ctx context.Context) (machines []*v1alpha5.Machine, err error) {
+	err = d.do(ctx, MethodList, func() error {
+		machines, err = d.CloudProvider.List(ctx)
+		return err
+	})
+	return machines, err
+}
+
+func (d *decorator) GetInstanceTypes(ctx context.Context, provisioner *v1alpha5.Provisioner) (instanceTypes []*cloudprovider.InstanceType, err error) {
+	err = d.do(ctx, MethodGetInstanceTypes, func() error {
+		instanceTypes, err = d.CloudProvider.GetInstanceTypes(ctx, provisioner)
+		return err
+	})
+	return instanceTypes, err
+}
+
+func (d *decorator) IsMachineDrifted(ctx context.Context, machine *v1alpha5.Machine) (drifted bool, err error) {
+	err = d.do(ctx, MethodIsMachineDrifted, func() error {
+		drifted, err = d.CloudProvider.IsMachineDrifted(ctx, machine)
+		return err
+	})
+	return drifted, err
+}
+
+// do calls f after waiting on the rate limit of the method, and retries it while it fails with a retryable error.
+// Retries wait on the rate limit as well.
+func (d *decorator) do(ctx context.Context, method string, f func() error) error {
+	return retry.Do(func() error {
+		if limiter, ok := d.limiters[method]; ok {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		return f()
+	},
+		retry.Context(ctx),
+		retry.Attempts(d.options.Retries+1),
+		retry.Delay(d.options.RetryDelay),
+		retry.MaxDelay(d.options.MaxRetryDelay),
+		retry.DelayType(retry.BackOffDelay),
+		retry.RetryIf(cloudprovider.IsRetryableError),
+		retry.LastErrorOnly(true),
+	)
+}
@@ -0,0 +1,202 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/resilience"
+)
+
+var ctx context.Context
+var fakeClock *clock.FakeClock
+var fakeCloudProvider *fake.CloudProvider
+var circuitBreaker *resilience.CircuitBreaker
+
+// unavailable is the error of a cloud provider that can't be reached
+var unavailable error = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+var cloudProvider cloudprovider.CloudProvider
+
+func TestResilience(t *testing.T) {
+	ctx = context.Background()
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CloudProvider/Resilience")
+}
+
+var _ = BeforeEach(func() {
+	fakeClock = clock.NewFakeClock(time.Now())
+	fakeCloudProvider = fake.NewCloudProvider()
+	circuitBreaker = resilience.NewCircuitBreaker(fakeClock, 2, time.Minute)
+	cloudProvider = resilience.Decorate(fakeCloudProvider, resilience.Options{
+		RateLimits: map[string]resilience.RateLimit{resilience.MethodGet: {QPS: 0.001, Burst: 1}},
+		Retries:    2,
+		RetryDelay: time.Millisecond,
+	}, circuitBreaker)
+})
+
+var _ = Describe("Resilience", func() {
+	var machine *v1alpha5.Machine
+	BeforeEach(func() {
+		machine = &v1alpha5.Machine{ObjectMeta: metav1.ObjectMeta{
+			Name:   "machine",
+			Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: "default"},
+		}}
+	})
+	Context("Retries", func() {
+		It("should retry retryable errors", func() {
+			fakeCloudProvider.NextCreateErr = cloudprovider.NewRetryableError(errors.New("throttled"))
+			created, err := cloudProvider.Create(ctx, machine)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created.Status.ProviderID).ToNot(BeEmpty())
+			Expect(fakeCloudProvider.CreateCalls).To(HaveLen(2))
+		})
+		It("should not retry other errors", func() {
+			fakeCloudProvider.NextCreateErr = errors.New("failed")
+			_, err := cloudProvider.Create(ctx, machine)
+			Expect(err).To(MatchError("failed"))
+			Expect(fakeCloudProvider.CreateCalls).To(HaveLen(1))
+		})
+		It("should retry machines of a batch that failed with retryable errors", func() {
+			fakeCloudProvider.SupportedCapabilities.BatchCreate = true
+			fakeCloudProvider.NextCreateErr = cloudprovider.NewRetryableError(errors.New("throttled"))
+			other := machine.DeepCopy()
+			other.Name = "other-machine"
+			results := cloudprovider.CreateBatch(ctx, cloudProvider, []*v1alpha5.Machine{machine, other})
+			Expect(results[0].Err).ToNot(HaveOccurred())
+			Expect(results[1].Err).ToNot(HaveOccurred())
+			Expect(fakeCloudProvider.CreateCalls).To(HaveLen(3))
+			Expect(fakeCloudProvider.CreateBatchCalls).To(HaveLen(2))
+			Expect(fakeCloudProvider.CreateBatchCalls[1]).To(ConsistOf(machine))
+			Expect(fakeCloudProvider.CreatedMachines).To(HaveKey(machine.Name))
+			Expect(fakeCloudProvider.CreatedMachines).To(HaveKey(other.Name))
+		})
+	})
+	Context("Rate Limits", func() {
+		It("should wait on the rate limit of the method", func() {
+			_, err := cloudProvider.Create(ctx, machine)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = cloudProvider.Get(ctx, machine.Name, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			// the burst is used up, and the next token isn't available before the deadline
+			timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			_, err = cloudProvider.Get(timeoutCtx, machine.Name, "")
+			Expect(err).To(HaveOccurred())
+			// other methods aren't limited
+			_, err = cloudProvider.List(timeoutCtx)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+	Context("Circuit Breaker", func() {
+		It("should open after repeated failures and reject creates until the cooldown elapses", func() {
+			for i := 0; i < 2; i++ {
+				fakeCloudProvider.NextCreateErr = unavailable
+				_, err := cloudProvider.Create(ctx, machine)
+				Expect(err).To(MatchError(unavailable))
+			}
+			Expect(circuitBreaker.Open("default")).To(HaveOccurred())
+
+			_, err := cloudProvider.Create(ctx, machine)
+			Expect(resilience.IsCircuitOpenError(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("connection refused")))
+			Expect(fakeCloudProvider.CreateCalls).To(HaveLen(2))
+
+			// other provisioners aren't affected
+			other := machine.DeepCopy()
+			other.Labels[v1alpha5.ProvisionerNameLabelKey] = "other"
+			_, err = cloudProvider.Create(ctx, other)
+			Expect(err).ToNot(HaveOccurred())
+
+			// a create is let through once the cooldown elapses, and closes the circuit when it succeeds
+			fakeClock.Step(time.Minute)
+			_, err = cloudProvider.Create(ctx, machine)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(circuitBreaker.Open("default")).To(Succeed())
+		})
+		It("should open again if the create after the cooldown fails", func() {
+			for i := 0; i < 2; i++ {
+				fakeCloudProvider.NextCreateErr = unavailable
+				_, _ = cloudProvider.Create(ctx, machine)
+			}
+			fakeClock.Step(time.Minute)
+			fakeCloudProvider.NextCreateErr = unavailable
+			_, err := cloudProvider.Create(ctx, machine)
+			Expect(err).To(MatchError(unavailable))
+
+			_, err = cloudProvider.Create(ctx, machine)
+			Expect(resilience.IsCircuitOpenError(err)).To(BeTrue())
+		})
+		It("should not count insufficient capacity errors", func() {
+			for i := 0; i < 2; i++ {
+				fakeCloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError("default-instance-type", "test-zone-1", v1alpha5.CapacityTypeSpot, errors.New("no capacity"))
+				_, _ = cloudProvider.Create(ctx, machine)
+			}
+			Expect(circuitBreaker.Open("default")).To(Succeed())
+		})
+		It("should not count errors of a cloud provider that is available", func() {
+			for i := 0; i < 2; i++ {
+				fakeCloudProvider.NextCreateErr = errors.New("invalid launch template")
+				_, _ = cloudProvider.Create(ctx, machine)
+			}
+			Expect(circuitBreaker.Open("default")).To(Succeed())
+		})
+		It("should count retryable errors that outlast the retries", func() {
+			circuitBreaker.Record(ctx, "default", cloudprovider.NewRetryableError(errors.New("throttled")))
+			circuitBreaker.Record(ctx, "default", cloudprovider.NewRetryableError(errors.New("throttled")))
+			Expect(circuitBreaker.Open("default")).To(HaveOccurred())
+		})
+		It("should reject the machines of a batch whose circuit is open", func() {
+			for i := 0; i < 2; i++ {
+				fakeCloudProvider.NextCreateErr = unavailable
+				_, _ = cloudProvider.Create(ctx, machine)
+			}
+			fakeCloudProvider.SupportedCapabilities.BatchCreate = true
+			other := machine.DeepCopy()
+			other.Name = "other-machine"
+			other.Labels[v1alpha5.ProvisionerNameLabelKey] = "other"
+			results := cloudprovider.CreateBatch(ctx, cloudProvider, []*v1alpha5.Machine{machine, other})
+			Expect(resilience.IsCircuitOpenError(results[0].Err)).To(BeTrue())
+			Expect(results[1].Err).ToNot(HaveOccurred())
+			Expect(fakeCloudProvider.CreatedMachines).To(HaveKey(other.Name))
+			Expect(fakeCloudProvider.CreateBatchCalls).To(ConsistOf(ConsistOf(other)))
+		})
+	})
+	Context("Notifications", func() {
+		It("should forward the notifications and instance type changes of the cloud provider", func() {
+			fakeCloudProvider.Notify(cloudprovider.Notification{Kind: cloudprovider.Interruption, ProviderID: "fake:///interrupted"})
+			Expect(cloudprovider.Notifications(cloudProvider)).To(Receive(HaveField("ProviderID", "fake:///interrupted")))
+			fakeCloudProvider.UpdatePrices(func(_ *cloudprovider.InstanceType, o cloudprovider.Offering) float64 { return o.Price })
+			Expect(cloudprovider.InstanceTypesChanged(cloudProvider)).To(Receive())
+		})
+		It("should not forward notifications of cloud providers that don't send them", func() {
+			cloudProvider = resilience.Decorate(struct{ cloudprovider.CloudProvider }{fakeCloudProvider}, resilience.DefaultOptions(), circuitBreaker)
+			Expect(cloudprovider.Notifications(cloudProvider)).To(BeNil())
+			Expect(cloudprovider.InstanceTypesChanged(cloudProvider)).To(BeNil())
+		})
+	})
+})
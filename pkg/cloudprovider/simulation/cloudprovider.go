@@ -0,0 +1,210 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+	"github.com/aws/karpenter-core/pkg/scheduling"
+	"github.com/aws/karpenter-core/pkg/utils/functional"
+	machineutil "github.com/aws/karpenter-core/pkg/utils/machine"
+	"github.com/aws/karpenter-core/pkg/utils/resources"
+)
+
+// ProviderIDPrefix is the prefix of the provider ids of simulated nodes, which identifies the nodes that the cloud
+// provider owns
+const ProviderIDPrefix = "simulation://"
+
+var _ cloudprovider.CloudProvider = (*CloudProvider)(nil)
+
+// CloudProvider "launches" machines by creating Ready nodes with the capacity, labels and provider id of the machine
+// directly in the API server, and "terminates" them by deleting the nodes. No pods actually run on the nodes, but it
+// lets the whole operator run against kind or envtest, exercising provisioning, consolidation and termination without
+// a cloud account.
+type CloudProvider struct {
+	kubeClient    client.Client
+	instanceTypes []*cloudprovider.InstanceType
+}
+
+// NewCloudProvider returns a simulation cloud provider that offers the instance types, or an assortment of fake
+// instance types if none are given
+func NewCloudProvider(kubeClient client.Client, instanceTypes []*cloudprovider.InstanceType) *CloudProvider {
+	if len(instanceTypes) == 0 {
+		instanceTypes = fake.InstanceTypesAssorted()
+	}
+	return &CloudProvider{
+		kubeClient:    kubeClient,
+		instanceTypes: instanceTypes,
+	}
+}
+
+func (c *CloudProvider) Create(ctx context.Context, machine *v1alpha5.Machine) (*v1alpha5.Machine, error) {
+	reqs := scheduling.NewNodeSelectorRequirements(machine.Spec.Requirements...)
+	instanceType, offering, ok := c.cheapest(reqs, machine.Spec.Resources.Requests)
+	if !ok {
+		return nil, fmt.Errorf("no instance type is compatible with the machine")
+	}
+	// the labels of the instance type are narrowed down to the values that the machine allows
+	labels := scheduling.NewRequirements(instanceType.Requirements.Values()...)
+	labels.Add(reqs.Values()...)
+	name := machine.Name
+	if name == "" {
+		name = strings.ToLower(machine.GenerateName + utilrand.String(5))
+	}
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: lo.Assign(machine.Labels, labels.Labels(), offering.Labels(), map[string]string{
+				v1.LabelInstanceTypeStable: instanceType.Name,
+				v1.LabelHostname:           name,
+			}),
+			Annotations: machine.Annotations,
+			Finalizers:  []string{v1alpha5.TerminationFinalizer},
+		},
+		Spec: v1.NodeSpec{
+			ProviderID: ProviderIDPrefix + name,
+			// startup taints are left out, as nothing would remove them from a simulated node
+			Taints: machine.Spec.Taints,
+		},
+	}
+	if err := c.kubeClient.Create(ctx, node); err != nil {
+		return nil, fmt.Errorf("creating node, %w", err)
+	}
+	stored := node.DeepCopy()
+	node.Status = v1.NodeStatus{
+		Capacity:    functional.FilterMap(instanceType.Capacity, func(_ v1.ResourceName, v resource.Quantity) bool { return !resources.IsZero(v) }),
+		Allocatable: functional.FilterMap(instanceType.Allocatable(), func(_ v1.ResourceName, v resource.Quantity) bool { return !resources.IsZero(v) }),
+		Phase:       v1.NodeRunning,
+		Conditions: []v1.NodeCondition{{
+			Type:               v1.NodeReady,
+			Status:             v1.ConditionTrue,
+			Reason:             "KubeletReady",
+			Message:            "simulated node is ready",
+			LastHeartbeatTime:  metav1.Now(),
+			LastTransitionTime: metav1.Now(),
+		}},
+	}
+	if err := c.kubeClient.Status().Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+		return nil, fmt.Errorf("marking node ready, %w", err)
+	}
+	logging.FromContext(ctx).With("node", node.Name).Debugf("created simulated node")
+	return c.toMachine(node), nil
+}
+
+func (c *CloudProvider) Get(ctx context.Context, machineName string, _ string) (*v1alpha5.Machine, error) {
+	node := &v1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: machineName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, cloudprovider.NewMachineNotFoundError(err)
+		}
+		return nil, fmt.Errorf("getting node, %w", err)
+	}
+	if !strings.HasPrefix(node.Spec.ProviderID, ProviderIDPrefix) {
+		return nil, cloudprovider.NewMachineNotFoundError(fmt.Errorf("node %s isn't simulated", machineName))
+	}
+	return c.toMachine(node), nil
+}
+
+func (c *CloudProvider) List(ctx context.Context) ([]*v1alpha5.Machine, error) {
+	nodeList := &v1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodeList); err != nil {
+		return nil, fmt.Errorf("listing nodes, %w", err)
+	}
+	return lo.FilterMap(nodeList.Items, func(n v1.Node, _ int) (*v1alpha5.Machine, bool) {
+		return c.toMachine(&n), strings.HasPrefix(n.Spec.ProviderID, ProviderIDPrefix)
+	}), nil
+}
+
+func (c *CloudProvider) Delete(ctx context.Context, machine *v1alpha5.Machine) error {
+	name := strings.TrimPrefix(machine.Status.ProviderID, ProviderIDPrefix)
+	if name == "" {
+		name = machine.Name
+	}
+	node := &v1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: name}, node); err != nil {
+		if errors.IsNotFound(err) {
+			return cloudprovider.NewMachineNotFoundError(err)
+		}
+		return fmt.Errorf("getting node, %w", err)
+	}
+	// the node is already being terminated, and goes away once the termination controller removes its finalizer
+	if !node.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if err := c.kubeClient.Delete(ctx, node); err != nil {
+		if errors.IsNotFound(err) {
+			return cloudprovider.NewMachineNotFoundError(err)
+		}
+		return fmt.Errorf("deleting node, %w", err)
+	}
+	return nil
+}
+
+func (c *CloudProvider) GetInstanceTypes(_ context.Context, _ *v1alpha5.Provisioner) ([]*cloudprovider.InstanceType, error) {
+	return c.instanceTypes, nil
+}
+
+func (c *CloudProvider) IsMachineDrifted(context.Context, *v1alpha5.Machine) (bool, error) {
+	return false, nil
+}
+
+// Name returns the CloudProvider implementation name.
+func (c *CloudProvider) Name() string {
+	return "simulation"
+}
+
+func (c *CloudProvider) Capabilities() cloudprovider.Capabilities {
+	return cloudprovider.Capabilities{Spot: true, Pricing: true, GetByName: true}
+}
+
+// cheapest returns the cheapest available offering that is compatible with the requirements, of an instance type that
+// fits the requests
+func (c *CloudProvider) cheapest(reqs scheduling.Requirements, requests v1.ResourceList) (*cloudprovider.InstanceType, cloudprovider.Offering, bool) {
+	var instanceType *cloudprovider.InstanceType
+	var offering cloudprovider.Offering
+	for _, it := range c.instanceTypes {
+		if reqs.Compatible(it.Requirements) != nil || !resources.Fits(requests, it.Allocatable()) {
+			continue
+		}
+		offerings := it.Offerings.Available().Requirements(reqs)
+		if len(offerings) == 0 {
+			continue
+		}
+		if o := offerings.Cheapest(); instanceType == nil || o.EffectivePrice() < offering.EffectivePrice() {
+			instanceType, offering = it, o
+		}
+	}
+	return instanceType, offering, instanceType != nil
+}
+
+func (c *CloudProvider) toMachine(node *v1.Node) *v1alpha5.Machine {
+	machine := machineutil.NewFromNode(node)
+	machine.CreationTimestamp = node.CreationTimestamp
+	return machine
+}
@@ -0,0 +1,141 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	. "knative.dev/pkg/logging/testing"
+
+	"github.com/aws/karpenter-core/pkg/apis"
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/simulation"
+	"github.com/aws/karpenter-core/pkg/operator/scheme"
+	"github.com/aws/karpenter-core/pkg/test"
+	. "github.com/aws/karpenter-core/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *test.Environment
+var cloudProvider *simulation.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CloudProvider/Simulation")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(scheme.Scheme, test.WithCRDs(apis.CRDs...))
+	cloudProvider = simulation.NewCloudProvider(env.Client, []*cloudprovider.InstanceType{
+		fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name: "small",
+			Resources: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("2"),
+				v1.ResourceMemory: resource.MustParse("2Gi"),
+			},
+		}),
+		fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name: "large",
+			Resources: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("8"),
+				v1.ResourceMemory: resource.MustParse("8Gi"),
+			},
+		}),
+	})
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Simulation", func() {
+	var machine *v1alpha5.Machine
+	BeforeEach(func() {
+		machine = &v1alpha5.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "default-",
+				Labels:       map[string]string{v1alpha5.ProvisionerNameLabelKey: "default"},
+			},
+			Spec: v1alpha5.MachineSpec{
+				Requirements: []v1.NodeSelectorRequirement{
+					{Key: v1alpha5.LabelCapacityType, Operator: v1.NodeSelectorOpIn, Values: []string{v1alpha5.CapacityTypeOnDemand}},
+				},
+				Taints:        []v1.Taint{{Key: "test", Effect: v1.TaintEffectNoSchedule}},
+				StartupTaints: []v1.Taint{{Key: "startup", Effect: v1.TaintEffectNoSchedule}},
+				Resources: v1alpha5.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+				},
+			},
+		}
+	})
+	It("should create a ready node for the cheapest compatible instance type", func() {
+		created, err := cloudProvider.Create(ctx, machine)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created.Status.ProviderID).To(HavePrefix(simulation.ProviderIDPrefix))
+
+		node := ExpectNodeExists(ctx, env.Client, created.Name)
+		Expect(node.Spec.ProviderID).To(Equal(created.Status.ProviderID))
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "large"))
+		Expect(node.Labels).To(HaveKeyWithValue(v1alpha5.ProvisionerNameLabelKey, "default"))
+		Expect(node.Labels).To(HaveKeyWithValue(v1alpha5.LabelCapacityType, v1alpha5.CapacityTypeOnDemand))
+		Expect(node.Labels).To(HaveKey(v1.LabelTopologyZone))
+		Expect(node.Spec.Taints).To(ConsistOf(machine.Spec.Taints))
+		Expect(node.Finalizers).To(ContainElement(v1alpha5.TerminationFinalizer))
+		Expect(node.Status.Allocatable.Cpu().Cmp(resource.MustParse("4"))).To(BeNumerically(">=", 0))
+		Expect(node.Status.Conditions).To(ContainElement(HaveField("Type", v1.NodeReady)))
+	})
+	It("should get and list the simulated nodes as machines", func() {
+		created, err := cloudProvider.Create(ctx, machine)
+		Expect(err).ToNot(HaveOccurred())
+		ExpectApplied(ctx, env.Client, test.Node(test.NodeOptions{ProviderID: "other://node"}))
+
+		retrieved, err := cloudProvider.Get(ctx, created.Name, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(retrieved.Status.ProviderID).To(Equal(created.Status.ProviderID))
+		machines, err := cloudProvider.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machines).To(HaveLen(1))
+		Expect(machines[0].Status.ProviderID).To(Equal(created.Status.ProviderID))
+	})
+	It("should delete the node of a machine", func() {
+		created, err := cloudProvider.Create(ctx, machine)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cloudProvider.Delete(ctx, created)).To(Succeed())
+		node := ExpectNodeExists(ctx, env.Client, created.Name)
+		Expect(node.DeletionTimestamp.IsZero()).To(BeFalse())
+
+		ExpectFinalizersRemoved(ctx, env.Client, &v1.NodeList{})
+		ExpectNotFound(ctx, env.Client, node)
+		Expect(cloudprovider.IsMachineNotFoundError(cloudProvider.Delete(ctx, created))).To(BeTrue())
+	})
+	It("should fail to create machines that no instance type fits", func() {
+		machine.Spec.Resources.Requests[v1.ResourceCPU] = resource.MustParse("64")
+		_, err := cloudProvider.Create(ctx, machine)
+		Expect(err).To(HaveOccurred())
+	})
+})
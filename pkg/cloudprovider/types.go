@@ -23,6 +23,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -55,6 +56,9 @@ type CloudProvider interface {
 	Delete(context.Context, *v1alpha5.Machine) error
 	// Get retrieves a machine from the cloudprovider by its machine name
 	Get(context.Context, string, string) (*v1alpha5.Machine, error)
+	// List retrieves all machines from the cloudprovider that were launched by Karpenter, including those that don't
+	// have a Machine or a Node. Returned machines must have their name, provider id and creation timestamp populated.
+	List(context.Context) ([]*v1alpha5.Machine, error)
 	// GetInstanceTypes returns instance types supported by the cloudprovider.
 	// Availability of types or zone may vary by provisioner or over time.  Regardless of
 	// availability, the GetInstanceTypes method should always return all instance types,
@@ -65,6 +69,54 @@ type CloudProvider interface {
 	IsMachineDrifted(context.Context, *v1alpha5.Machine) (bool, error)
 	// Name returns the CloudProvider implementation name.
 	Name() string
+	// Capabilities returns the optional features that the CloudProvider supports. Controllers fall back to a
+	// reduced behavior for the features that aren't supported.
+	Capabilities() Capabilities
+}
+
+// BatchCreator is an optional extension of CloudProvider for cloud providers that can launch many machines in a single
+// call, e.g. with a fleet API, which reduces API throttling. It's only used if the CloudProvider also reports the
+// BatchCreate capability.
+type BatchCreator interface {
+	// CreateBatch launches the machines and returns a result for each of them, in the same order as the machines
+	CreateBatch(context.Context, []*v1alpha5.Machine) []CreateResult
+}
+
+// CreateResult is the result of launching a single machine of a batch. Either the hydrated machine or the error that
+// prevented it from launching is set.
+type CreateResult struct {
+	Machine *v1alpha5.Machine
+	Err     error
+}
+
+// CreateBatch launches the machines with a single call if the CloudProvider supports batch create, and with a call
+// to Create per machine otherwise
+func CreateBatch(ctx context.Context, cloudProvider CloudProvider, machines []*v1alpha5.Machine) []CreateResult {
+	if batchCreator, ok := cloudProvider.(BatchCreator); ok && cloudProvider.Capabilities().BatchCreate {
+		return batchCreator.CreateBatch(ctx, machines)
+	}
+	results := make([]CreateResult, len(machines))
+	workqueue.ParallelizeUntil(ctx, len(machines), len(machines), func(i int) {
+		results[i].Machine, results[i].Err = cloudProvider.Create(ctx, machines[i])
+	})
+	return results
+}
+
+// Capabilities describes the optional features that a CloudProvider supports
+type Capabilities struct {
+	// Drift is true if IsMachineDrifted detects drift. If false, nodes are only considered drifted when their
+	// provisioner changes.
+	Drift bool
+	// Spot is true if the CloudProvider can launch spot capacity
+	Spot bool
+	// Pricing is true if the offerings of instance types have prices. If false, consolidation can delete nodes but
+	// can't replace them, since it can't tell whether a replacement is cheaper.
+	Pricing bool
+	// BatchCreate is true if the CloudProvider can launch several machines in a single call
+	BatchCreate bool
+	// GetByName is true if Get can retrieve a machine by its name. If false, machines are created without first
+	// checking whether a previous launch for the same machine succeeded.
+	GetByName bool
 }
 
 // InstanceType describes the properties of a potential node (either concrete attributes of an instance of this type
@@ -106,7 +158,13 @@ func (i InstanceTypeOverhead) Total() v1.ResourceList {
 type Offering struct {
 	CapacityType string
 	Zone         string
+	// Requirements are the properties of the offering beyond its zone and capacity type, e.g. a reservation ID, a
+	// placement group or the tenancy. The offering is only compatible with requirements that intersect them, and keys
+	// that aren't set don't constrain the offering. Instance types should include the keys in their requirements.
+	Requirements scheduling.Requirements
 	Price        float64
+	// ReservationCapacity is the number of nodes that can still be launched into the reservation of a reserved offering
+	ReservationCapacity int
 	// Available is added so that Offerings can return all offerings that have ever existed for an instance type,
 	// so we can get historical pricing data for calculating savings in consolidation
 	Available bool
@@ -122,28 +180,53 @@ func (ofs Offerings) Get(ct, zone string) (Offering, bool) {
 	})
 }
 
-// Available filters the available offerings from the returned offerings
+// Available filters the available offerings from the returned offerings. Reserved offerings are only available while
+// their reservation has capacity left.
 func (ofs Offerings) Available() Offerings {
 	return lo.Filter(ofs, func(o Offering, _ int) bool {
-		return o.Available
+		return o.Available && (o.CapacityType != v1alpha5.CapacityTypeReserved || o.ReservationCapacity > 0)
 	})
 }
 
 // Requirements filters the offerings based on the passed requirements
 func (ofs Offerings) Requirements(reqs scheduling.Requirements) Offerings {
 	return lo.Filter(ofs, func(offering Offering, _ int) bool {
-		return (!reqs.Has(v1.LabelTopologyZone) || reqs.Get(v1.LabelTopologyZone).Has(offering.Zone)) &&
-			(!reqs.Has(v1alpha5.LabelCapacityType) || reqs.Get(v1alpha5.LabelCapacityType).Has(offering.CapacityType))
+		return offering.Compatible(reqs)
+	})
+}
+
+// Compatible returns true if the zone, the capacity type and the requirements of the offering intersect the passed
+// requirements
+func (o Offering) Compatible(reqs scheduling.Requirements) bool {
+	return (!reqs.Has(v1.LabelTopologyZone) || reqs.Get(v1.LabelTopologyZone).Has(o.Zone)) &&
+		(!reqs.Has(v1alpha5.LabelCapacityType) || reqs.Get(v1alpha5.LabelCapacityType).Has(o.CapacityType)) &&
+		reqs.Intersects(o.Requirements) == nil
+}
+
+// Labels returns the labels of a node launched with the offering
+func (o Offering) Labels() map[string]string {
+	return lo.Assign(o.Requirements.Labels(), map[string]string{
+		v1.LabelTopologyZone:       o.Zone,
+		v1alpha5.LabelCapacityType: o.CapacityType,
 	})
 }
 
 // Cheapest returns the cheapest offering from the returned offerings
 func (ofs Offerings) Cheapest() Offering {
 	return lo.MinBy(ofs, func(a, b Offering) bool {
-		return a.Price < b.Price
+		return a.EffectivePrice() < b.EffectivePrice()
 	})
 }
 
+// EffectivePrice is the price of running a node with the offering. Reserved capacity is paid for whether it is used
+// or not, so nodes launched into a reservation are free.
+func (o Offering) EffectivePrice() float64 {
+	if o.CapacityType == v1alpha5.CapacityTypeReserved {
+		return 0
+	}
+	return o.Price
+}
+
 // MachineNotFoundError is an error type returned by CloudProviders when the reason for failure is NotFound
 type MachineNotFoundError struct {
 	Err error
@@ -173,3 +256,65 @@ func IgnoreMachineNotFoundError(err error) error {
 	}
 	return err
 }
+
+// InsufficientCapacityError is an error type returned by CloudProviders when a machine can't be launched because the
+// cloud provider doesn't have capacity for an offering
+type InsufficientCapacityError struct {
+	InstanceType string
+	Zone         string
+	CapacityType string
+	Err          error
+}
+
+func NewInsufficientCapacityError(instanceType, zone, capacityType string, err error) *InsufficientCapacityError {
+	return &InsufficientCapacityError{
+		InstanceType: instanceType,
+		Zone:         zone,
+		CapacityType: capacityType,
+		Err:          err,
+	}
+}
+
+func (e *InsufficientCapacityError) Error() string {
+	return fmt.Sprintf("insufficient capacity for %s in %s (%s), %s", e.InstanceType, e.Zone, e.CapacityType, e.Err)
+}
+
+func (e *InsufficientCapacityError) Unwrap() error {
+	return e.Err
+}
+
+func IsInsufficientCapacityError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var iceErr *InsufficientCapacityError
+	return errors.As(err, &iceErr)
+}
+
+// RetryableError is an error type returned by CloudProviders when a call failed for a transient reason, e.g. throttling,
+// and is safe to retry
+type RetryableError struct {
+	Err error
+}
+
+func NewRetryableError(err error) *RetryableError {
+	return &RetryableError{
+		Err: err,
+	}
+}
+
+func (e *RetryableError) Error() string {
+	return fmt.Sprintf("retryable, %s", e.Err)
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var retryableErr *RetryableError
+	return errors.As(err, &retryableErr)
+}
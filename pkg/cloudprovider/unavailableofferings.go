@@ -0,0 +1,136 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+)
+
+// UnavailableOfferingsTTL is how long an offering is considered unavailable after the cloud provider ran out of
+// capacity for it
+const UnavailableOfferingsTTL = 3 * time.Minute
+
+// UnavailableOfferings is a TTL cache of the offerings that the cloud provider recently didn't have capacity for
+type UnavailableOfferings struct {
+	mu      sync.RWMutex
+	clock   clock.Clock
+	expires map[string]time.Time
+}
+
+func NewUnavailableOfferings(clk clock.Clock) *UnavailableOfferings {
+	return &UnavailableOfferings{
+		clock:   clk,
+		expires: map[string]time.Time{},
+	}
+}
+
+// IsUnavailable returns true if the offering was marked unavailable within the TTL
+func (u *UnavailableOfferings) IsUnavailable(instanceType, zone, capacityType string) bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	expires, ok := u.expires[u.key(instanceType, zone, capacityType)]
+	return ok && u.clock.Now().Before(expires)
+}
+
+// MarkUnavailable marks the offering as unavailable for the TTL
+func (u *UnavailableOfferings) MarkUnavailable(ctx context.Context, instanceType, zone, capacityType string) {
+	logging.FromContext(ctx).With("instance-type", instanceType, "zone", zone, "capacity-type", capacityType, "ttl", UnavailableOfferingsTTL).
+		Debugf("removing offering from offerings")
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := u.clock.Now()
+	for key, expires := range u.expires {
+		if !now.Before(expires) {
+			delete(u.expires, key)
+		}
+	}
+	u.expires[u.key(instanceType, zone, capacityType)] = now.Add(UnavailableOfferingsTTL)
+}
+
+// Apply returns the instance types with the unavailable offerings marked as not available. Instance types are copied
+// rather than modified, as cloud providers may cache them.
+func (u *UnavailableOfferings) Apply(instanceTypes []*InstanceType) []*InstanceType {
+	return lo.Map(instanceTypes, func(it *InstanceType, _ int) *InstanceType {
+		if !lo.ContainsBy(it.Offerings, func(o Offering) bool { return o.Available && u.IsUnavailable(it.Name, o.Zone, o.CapacityType) }) {
+			return it
+		}
+		copied := *it
+		copied.Offerings = lo.Map(it.Offerings, func(o Offering, _ int) Offering {
+			o.Available = o.Available && !u.IsUnavailable(it.Name, o.Zone, o.CapacityType)
+			return o
+		})
+		return &copied
+	})
+}
+
+func (u *UnavailableOfferings) key(instanceType, zone, capacityType string) string {
+	return fmt.Sprintf("%s:%s:%s", capacityType, instanceType, zone)
+}
+
+type unavailableOfferingsDecorator struct {
+	CloudProvider
+	Forwarder
+	unavailableOfferings *UnavailableOfferings
+}
+
+// DecorateWithUnavailableOfferings returns a CloudProvider that marks offerings unavailable when creating a machine
+// fails with an InsufficientCapacityError, and hides those offerings from the instance types that it returns until
+// the TTL expires. This keeps the scheduler and consolidation from repeatedly picking exhausted offerings.
+//
+// It must be the outermost decorator of the cloud provider. Instance types that are cached by an inner decorator
+// would otherwise keep offerings that were marked unavailable after they were cached, or keep hiding them after the
+// TTL expires.
+func DecorateWithUnavailableOfferings(cloudProvider CloudProvider, unavailableOfferings *UnavailableOfferings) CloudProvider {
+	return &unavailableOfferingsDecorator{CloudProvider: cloudProvider, Forwarder: Forwarder{CloudProvider: cloudProvider}, unavailableOfferings: unavailableOfferings}
+}
+
+func (d *unavailableOfferingsDecorator) Create(ctx context.Context, machine *v1alpha5.Machine) (*v1alpha5.Machine, error) {
+	created, err := d.CloudProvider.Create(ctx, machine)
+	d.markUnavailable(ctx, err)
+	return created, err
+}
+
+func (d *unavailableOfferingsDecorator) CreateBatch(ctx context.Context, machines []*v1alpha5.Machine) []CreateResult {
+	results := CreateBatch(ctx, d.CloudProvider, machines)
+	for _, result := range results {
+		d.markUnavailable(ctx, result.Err)
+	}
+	return results
+}
+
+func (d *unavailableOfferingsDecorator) markUnavailable(ctx context.Context, err error) {
+	var iceErr *InsufficientCapacityError
+	if errors.As(err, &iceErr) {
+		d.unavailableOfferings.MarkUnavailable(ctx, iceErr.InstanceType, iceErr.Zone, iceErr.CapacityType)
+	}
+}
+
+func (d *unavailableOfferingsDecorator) GetInstanceTypes(ctx context.Context, provisioner *v1alpha5.Provisioner) ([]*InstanceType, error) {
+	instanceTypes, err := d.CloudProvider.GetInstanceTypes(ctx, provisioner)
+	if err != nil {
+		return nil, err
+	}
+	return d.unavailableOfferings.Apply(instanceTypes), nil
+}
@@ -21,14 +21,20 @@ import (
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/aws/karpenter-core/pkg/apis/settings"
 	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/resilience"
 	"github.com/aws/karpenter-core/pkg/controllers/counter"
 	"github.com/aws/karpenter-core/pkg/controllers/deprovisioning"
+	"github.com/aws/karpenter-core/pkg/controllers/garbagecollection"
 	"github.com/aws/karpenter-core/pkg/controllers/inflightchecks"
+	"github.com/aws/karpenter-core/pkg/controllers/instancetypecache"
+	"github.com/aws/karpenter-core/pkg/controllers/interruption"
 	"github.com/aws/karpenter-core/pkg/controllers/machine/terminator"
 	metricspod "github.com/aws/karpenter-core/pkg/controllers/metrics/pod"
 	metricsprovisioner "github.com/aws/karpenter-core/pkg/controllers/metrics/provisioner"
 	metricsstate "github.com/aws/karpenter-core/pkg/controllers/metrics/state"
+	"github.com/aws/karpenter-core/pkg/controllers/minimumcapacity"
 	"github.com/aws/karpenter-core/pkg/controllers/node"
 	"github.com/aws/karpenter-core/pkg/controllers/provisioning"
 	"github.com/aws/karpenter-core/pkg/controllers/state"
@@ -53,13 +59,34 @@ func NewControllers(
 	cloudProvider cloudprovider.CloudProvider,
 ) []controller.Controller {
 
+	// Calls to the cloud provider are retried on transient errors, and creates for a provisioner are rejected for a
+	// while after repeated failures rather than piling up on a cloud provider that is down
+	s := settings.FromContext(ctx)
+	circuitBreaker := resilience.NewCircuitBreaker(clock, s.CircuitBreakerThreshold, s.CircuitBreakerCooldown.Duration)
+	resilienceOptions := resilience.DefaultOptions()
+	resilienceOptions.Retries = uint(s.CloudProviderRetries)
+	cloudProvider = resilience.Decorate(cloudProvider, resilienceOptions, circuitBreaker)
+	// Instance types are shared by the controllers that need them, rather than each of them calling the cloud provider
+	instanceTypeCache := cloudprovider.NewInstanceTypeCache(clock)
+	cloudProvider = cloudprovider.DecorateWithInstanceTypeCache(cloudProvider, instanceTypeCache)
+	// Offerings that the cloud provider runs out of capacity for are hidden from the controllers for a while, so that
+	// they aren't retried by the next scheduling or consolidation pass
+	cloudProvider = cloudprovider.DecorateWithUnavailableOfferings(cloudProvider, cloudprovider.NewUnavailableOfferings(clock))
+	// The decorators forward the notifications of the cloud provider, which are nil if it doesn't send them
+	if changed := cloudprovider.InstanceTypesChanged(cloudProvider); changed != nil {
+		// A change in prices or availability can make cheaper replacements possible, so consolidation is re-evaluated
+		// rather than waiting for the cluster itself to change
+		instanceTypeCache.OnChange(func() { cluster.SetConsolidated(false) })
+		instanceTypeCache.Watch(ctx, changed)
+	}
 	provisioner := provisioning.NewProvisioner(ctx, kubeClient, kubernetesInterface.CoreV1(), recorder, cloudProvider, cluster)
 	terminator := terminator.NewTerminator(clock, kubeClient, cloudProvider, terminator.NewEvictionQueue(ctx, kubernetesInterface.CoreV1(), recorder))
-	return []controller.Controller{
+	controllers := []controller.Controller{
 		provisioner,
 		metricsstate.NewController(cluster),
 		deprovisioning.NewController(clock, kubeClient, provisioner, cloudProvider, recorder, cluster),
 		provisioning.NewController(kubeClient, provisioner, recorder),
+		minimumcapacity.NewController(kubeClient, provisioner, cloudProvider, cluster),
 		informer.NewNodeController(kubeClient, cluster),
 		informer.NewPodController(kubeClient, cluster),
 		informer.NewProvisionerController(kubeClient, cluster),
@@ -67,7 +94,13 @@ func NewControllers(
 		termination.NewController(kubeClient, terminator, recorder),
 		metricspod.NewController(kubeClient),
 		metricsprovisioner.NewController(kubeClient),
-		counter.NewController(kubeClient, cluster),
+		counter.NewController(clock, kubeClient, recorder, cloudProvider, cluster, circuitBreaker),
 		inflightchecks.NewController(clock, kubeClient, recorder, cloudProvider),
+		garbagecollection.NewController(clock, kubeClient, cloudProvider),
+		instancetypecache.NewController(kubeClient, instanceTypeCache),
+	}
+	if notifications := cloudprovider.Notifications(cloudProvider); notifications != nil {
+		controllers = append(controllers, interruption.NewController(clock, kubeClient, recorder, cluster, provisioner, terminator, notifications))
 	}
+	return controllers
 }
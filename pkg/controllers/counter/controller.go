@@ -16,16 +16,26 @@ package counter
 
 import (
 	"context"
+	"sync"
+	"time"
 
+	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/apis"
 
 	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/resilience"
 	"github.com/aws/karpenter-core/pkg/controllers/state"
+	"github.com/aws/karpenter-core/pkg/events"
 	corecontroller "github.com/aws/karpenter-core/pkg/operator/controller"
+	"github.com/aws/karpenter-core/pkg/scheduling"
 	"github.com/aws/karpenter-core/pkg/utils/functional"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -40,17 +50,44 @@ import (
 
 var _ corecontroller.TypedController[*v1alpha5.Provisioner] = (*Controller)(nil)
 
+// cloudProviderErrorTTL is how long a failure to create a machine marks the cloud provider as unhealthy
+const cloudProviderErrorTTL = 10 * time.Minute
+
+// instanceTypesCheckInterval is how often the availability of the instance types of a provisioner is checked. Nodes
+// trigger reconciles far more often than instance types change, so they reuse the result of the last check.
+const instanceTypesCheckInterval = time.Minute
+
 // Controller for the resource
 type Controller struct {
-	kubeClient client.Client
-	cluster    *state.Cluster
+	clock          clock.Clock
+	kubeClient     client.Client
+	recorder       events.Recorder
+	cloudProvider  cloudprovider.CloudProvider
+	cluster        *state.Cluster
+	circuitBreaker *resilience.CircuitBreaker
+
+	mu sync.Mutex
+	// instanceTypesChecks are the last checks of the instance types of the provisioners, keyed by provisioner name
+	instanceTypesChecks map[string]instanceTypesCheck
+}
+
+type instanceTypesCheck struct {
+	generation int64
+	time       time.Time
 }
 
 // NewController is a constructor
-func NewController(kubeClient client.Client, cluster *state.Cluster) corecontroller.Controller {
+func NewController(clk clock.Clock, kubeClient client.Client, recorder events.Recorder, cloudProvider cloudprovider.CloudProvider,
+	cluster *state.Cluster, circuitBreaker *resilience.CircuitBreaker) corecontroller.Controller {
 	return corecontroller.Typed[*v1alpha5.Provisioner](kubeClient, &Controller{
-		kubeClient: kubeClient,
-		cluster:    cluster,
+		clock:          clk,
+		kubeClient:     kubeClient,
+		recorder:       recorder,
+		cloudProvider:  cloudProvider,
+		cluster:        cluster,
+		circuitBreaker: circuitBreaker,
+
+		instanceTypesChecks: map[string]instanceTypesCheck{},
 	})
 }
 
@@ -62,17 +99,122 @@ func (c *Controller) Name() string {
 func (c *Controller) Reconcile(ctx context.Context, provisioner *v1alpha5.Provisioner) (reconcile.Result, error) {
 	stored := provisioner.DeepCopy()
 	// Determine resource usage and update provisioner.status.resources
-	provisioner.Status.Resources = c.resourceCountsFor(provisioner.Name)
+	provisioner.Status.Resources, provisioner.Status.Nodes = c.resourceCountsFor(provisioner.Name)
+	history := c.cluster.LaunchHistory(provisioner.Name)
+	if !history.LastLaunchTime.IsZero() {
+		provisioner.Status.LastLaunchTime = &apis.VolatileTime{Inner: metav1.NewTime(history.LastLaunchTime)}
+	}
+	c.updateConditions(ctx, provisioner, history)
 	if !equality.Semantic.DeepEqual(stored, provisioner) {
 		if err := c.kubeClient.Status().Patch(ctx, provisioner, client.MergeFrom(stored)); err != nil {
 			return reconcile.Result{}, err
 		}
 	}
-	return reconcile.Result{}, nil
+	// Requeue to pick up changes in instance type availability and launch failures, which don't trigger a reconcile
+	return reconcile.Result{RequeueAfter: instanceTypesCheckInterval}, nil
+}
+
+// updateConditions sets the conditions that explain whether the provisioner is able to launch nodes
+func (c *Controller) updateConditions(ctx context.Context, provisioner *v1alpha5.Provisioner, history state.LaunchHistory) {
+	conditions := provisioner.StatusConditions()
+	var blocked *apis.Condition
+
+	wasPaused := conditions.GetCondition(v1alpha5.ProvisionerPaused).IsTrue()
+	if provisioner.Spec.Paused {
+		conditions.MarkTrueWithReason(v1alpha5.ProvisionerPaused, "Paused", "Nodes won't be launched or deprovisioned")
+		blocked = conditions.GetCondition(v1alpha5.ProvisionerPaused)
+		if !wasPaused {
+			c.recorder.Publish(events.ProvisionerPaused(provisioner))
+		}
+	} else {
+		conditions.MarkFalse(v1alpha5.ProvisionerPaused, "NotPaused", "")
+		if wasPaused {
+			c.recorder.Publish(events.ProvisionerResumed(provisioner))
+		}
+	}
+
+	// limits that are reached block provisioning just like exceeded ones, as any node that is launched would exceed them
+	if limitsErr := provisioner.Spec.Limits.ReachedBy(provisioner.Status.Resources, provisioner.Status.Nodes); limitsErr != nil {
+		conditions.MarkTrueWithReason(v1alpha5.ProvisionerLimitsExceeded, "LimitsExceeded", "%s", limitsErr)
+		if blocked == nil {
+			blocked = conditions.GetCondition(v1alpha5.ProvisionerLimitsExceeded)
+		}
+	} else {
+		conditions.MarkFalse(v1alpha5.ProvisionerLimitsExceeded, "WithinLimits", "")
+	}
+
+	if c.shouldCheckInstanceTypes(provisioner) {
+		c.checkInstanceTypes(ctx, provisioner)
+	}
+	if blocked == nil && !conditions.GetCondition(v1alpha5.ProvisionerInstanceTypesAvailable).IsTrue() {
+		blocked = conditions.GetCondition(v1alpha5.ProvisionerInstanceTypesAvailable)
+	}
+
+	// The cloud provider is considered unhealthy while creates are rejected by the circuit breaker, or if the last
+	// attempt to create a machine failed recently
+	if err := c.circuitBreaker.Open(provisioner.Name); err != nil {
+		conditions.MarkFalse(v1alpha5.ProvisionerCloudProviderHealthy, "CircuitOpen", "%s", err)
+	} else if history.LastError != nil && history.LastErrorTime.After(history.LastLaunchTime) &&
+		c.clock.Since(history.LastErrorTime) < cloudProviderErrorTTL {
+		conditions.MarkFalse(v1alpha5.ProvisionerCloudProviderHealthy, "CreateFailed", "%s", history.LastError)
+	} else {
+		conditions.MarkTrue(v1alpha5.ProvisionerCloudProviderHealthy)
+	}
+	if blocked == nil && !conditions.GetCondition(v1alpha5.ProvisionerCloudProviderHealthy).IsTrue() {
+		blocked = conditions.GetCondition(v1alpha5.ProvisionerCloudProviderHealthy)
+	}
+
+	// Maintenance windows only gate voluntary disruption, so they don't block provisioning. They're evaluated here so
+	// that a window that can't be evaluated is reported once for the provisioner, rather than for each of its nodes.
+	if _, err := provisioner.Spec.Disruption.InMaintenanceWindow(c.clock.Now()); err != nil {
+		conditions.MarkFalse(v1alpha5.ProvisionerMaintenanceWindowsValid, "InvalidMaintenanceWindows", "%s", err)
+	} else {
+		conditions.MarkTrue(v1alpha5.ProvisionerMaintenanceWindowsValid)
+	}
+
+	if blocked != nil {
+		conditions.MarkTrueWithReason(v1alpha5.ProvisionerProvisioningBlocked, blocked.Reason, "%s", blocked.Message)
+	} else {
+		conditions.MarkFalse(v1alpha5.ProvisionerProvisioningBlocked, "NotBlocked", "")
+	}
+}
+
+// shouldCheckInstanceTypes returns true if the instance types of the provisioner haven't been checked within the
+// interval, or its spec changed since they were
+func (c *Controller) shouldCheckInstanceTypes(provisioner *v1alpha5.Provisioner) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last, ok := c.instanceTypesChecks[provisioner.Name]
+	if ok && last.generation == provisioner.Generation && c.clock.Since(last.time) < instanceTypesCheckInterval &&
+		provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerInstanceTypesAvailable) != nil {
+		return false
+	}
+	c.instanceTypesChecks[provisioner.Name] = instanceTypesCheck{generation: provisioner.Generation, time: c.clock.Now()}
+	return true
+}
+
+// checkInstanceTypes sets whether any available instance types are compatible with the provisioner requirements
+func (c *Controller) checkInstanceTypes(ctx context.Context, provisioner *v1alpha5.Provisioner) {
+	conditions := provisioner.StatusConditions()
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, provisioner)
+	requirements := scheduling.NewNodeSelectorRequirements(provisioner.Spec.NodeSelectorRequirements()...)
+	available := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return len(it.Offerings.Available()) > 0 && requirements.Compatible(it.Requirements) == nil
+	})
+	switch {
+	case err != nil:
+		conditions.MarkFalse(v1alpha5.ProvisionerInstanceTypesAvailable, "GettingInstanceTypesFailed", "%s", err)
+	case len(available) == 0:
+		conditions.MarkFalse(v1alpha5.ProvisionerInstanceTypesAvailable, "NoInstanceTypesAvailable",
+			"No available instance types are compatible with the provisioner requirements")
+	default:
+		conditions.MarkTrue(v1alpha5.ProvisionerInstanceTypesAvailable)
+	}
 }
 
-func (c *Controller) resourceCountsFor(provisionerName string) v1.ResourceList {
+func (c *Controller) resourceCountsFor(provisionerName string) (v1.ResourceList, int64) {
 	var res v1.ResourceList
+	var nodes int64
 	// Record all resources provisioned by the provisioners, we look at the cluster state nodes as their capacity
 	// is accurately reported even for nodes that haven't fully started yet. This allows us to update our provisioner
 	// status immediately upon node creation instead of waiting for the node to become ready.
@@ -84,10 +226,11 @@ func (c *Controller) resourceCountsFor(provisionerName string) v1.ResourceList {
 		}
 		if n.Labels()[v1alpha5.ProvisionerNameLabelKey] == provisionerName {
 			res = resources.Merge(res, n.Capacity())
+			nodes++
 		}
 		return true
 	})
-	return functional.FilterMap(res, func(_ v1.ResourceName, v resource.Quantity) bool { return !v.IsZero() })
+	return functional.FilterMap(res, func(_ v1.ResourceName, v resource.Quantity) bool { return !v.IsZero() }), nodes
 }
 
 func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
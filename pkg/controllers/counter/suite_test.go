@@ -0,0 +1,277 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package counter_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	. "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/aws/karpenter-core/pkg/apis"
+	"github.com/aws/karpenter-core/pkg/apis/settings"
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/resilience"
+	"github.com/aws/karpenter-core/pkg/controllers/counter"
+	"github.com/aws/karpenter-core/pkg/controllers/state"
+	"github.com/aws/karpenter-core/pkg/controllers/state/informer"
+	"github.com/aws/karpenter-core/pkg/events"
+	"github.com/aws/karpenter-core/pkg/operator/controller"
+	"github.com/aws/karpenter-core/pkg/operator/scheme"
+	"github.com/aws/karpenter-core/pkg/test"
+	. "github.com/aws/karpenter-core/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cluster *state.Cluster
+var cloudProvider *fake.CloudProvider
+var nodeController controller.Controller
+var counterController controller.Controller
+var recorder *record.FakeRecorder
+var provisioner *v1alpha5.Provisioner
+var circuitBreaker *resilience.CircuitBreaker
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Controllers/Counter")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(scheme.Scheme, test.WithCRDs(apis.CRDs...))
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = settings.ToContext(ctx, test.Settings())
+	cloudProvider = fake.NewCloudProvider()
+	fakeClock = clock.NewFakeClock(time.Now())
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	nodeController = informer.NewNodeController(env.Client, cluster)
+	recorder = record.NewFakeRecorder(10)
+	circuitBreaker = resilience.NewCircuitBreaker(fakeClock, 2, time.Minute)
+	counterController = counter.NewController(fakeClock, env.Client, events.NewRecorder(recorder), cloudProvider, cluster, circuitBreaker)
+	provisioner = test.Provisioner()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Counter", func() {
+	It("should count the resources and nodes of the provisioner", func() {
+		nodes := []*v1.Node{
+			test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}},
+				Capacity:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+			}),
+			test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}},
+				Capacity:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+			}),
+		}
+		ExpectApplied(ctx, env.Client, provisioner, nodes[0], nodes[1])
+		for _, node := range nodes {
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		}
+		ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+		provisioner = ExpectExists(ctx, env.Client, provisioner)
+		Expect(provisioner.Status.Nodes).To(BeNumerically("==", 2))
+		Expect(provisioner.Status.Resources.Cpu().String()).To(Equal("6"))
+	})
+	It("should set the last launch time", func() {
+		ExpectApplied(ctx, env.Client, provisioner)
+		cluster.RecordLaunch(provisioner.Name, nil)
+		ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+		provisioner = ExpectExists(ctx, env.Client, provisioner)
+		Expect(provisioner.Status.LastLaunchTime).ToNot(BeNil())
+		Expect(provisioner.Status.LastLaunchTime.Inner.Unix()).To(Equal(fakeClock.Now().Unix()))
+	})
+	Context("Conditions", func() {
+		It("should be active and not blocked when the provisioner can launch nodes", func() {
+			ExpectApplied(ctx, env.Client, provisioner)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().IsHappy()).To(BeTrue())
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerLimitsExceeded).IsFalse()).To(BeTrue())
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerProvisioningBlocked).IsFalse()).To(BeTrue())
+		})
+		It("should be blocked when the limits are exceeded", func() {
+			provisioner.Spec.Limits.Nodes = ptr.Int64(0)
+			node := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}},
+			})
+			ExpectApplied(ctx, env.Client, provisioner, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerLimitsExceeded).IsTrue()).To(BeTrue())
+			blocked := provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerProvisioningBlocked)
+			Expect(blocked.IsTrue()).To(BeTrue())
+			Expect(blocked.Reason).To(Equal("LimitsExceeded"))
+		})
+		It("should be blocked when the limits are reached", func() {
+			provisioner.Spec.Limits.Nodes = ptr.Int64(1)
+			node := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}},
+			})
+			ExpectApplied(ctx, env.Client, provisioner, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerLimitsExceeded).IsTrue()).To(BeTrue())
+		})
+		It("should be blocked when no instance types are available", func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{Name: "unavailable", Offerings: []cloudprovider.Offering{
+					{CapacityType: v1alpha5.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 1, Available: false},
+				}}),
+			}
+			ExpectApplied(ctx, env.Client, provisioner)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().IsHappy()).To(BeFalse())
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerInstanceTypesAvailable).IsFalse()).To(BeTrue())
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerProvisioningBlocked).Reason).To(Equal("NoInstanceTypesAvailable"))
+		})
+		It("should only check the instance types again once the check interval passed", func() {
+			ExpectApplied(ctx, env.Client, provisioner)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerInstanceTypesAvailable).IsTrue()).To(BeTrue())
+
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{Name: "unavailable", Offerings: []cloudprovider.Offering{
+					{CapacityType: v1alpha5.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 1, Available: false},
+				}}),
+			}
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerInstanceTypesAvailable).IsTrue()).To(BeTrue())
+
+			fakeClock.Step(time.Minute)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerInstanceTypesAvailable).IsFalse()).To(BeTrue())
+		})
+		It("should be blocked when the provisioner requirements exclude all instance types", func() {
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"does-not-exist"}}},
+			}
+			ExpectApplied(ctx, env.Client, provisioner)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerInstanceTypesAvailable).IsFalse()).To(BeTrue())
+		})
+		It("should report maintenance windows that can't be evaluated without blocking provisioning", func() {
+			provisioner.Spec.Disruption = &v1alpha5.Disruption{MaintenanceWindows: []v1alpha5.MaintenanceWindow{
+				{Schedule: "@daily", TimeZone: ptr.String("Invalid/Zone"), Duration: metav1.Duration{Duration: time.Hour}},
+			}}
+			ExpectApplied(ctx, env.Client, provisioner)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			valid := provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerMaintenanceWindowsValid)
+			Expect(valid.IsFalse()).To(BeTrue())
+			Expect(valid.Message).To(ContainSubstring("Invalid/Zone"))
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerProvisioningBlocked).IsFalse()).To(BeTrue())
+		})
+		It("should be blocked while the cloud provider recently failed to create machines", func() {
+			ExpectApplied(ctx, env.Client, provisioner)
+			cluster.RecordLaunch(provisioner.Name, fmt.Errorf("insufficient capacity"))
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerCloudProviderHealthy).IsFalse()).To(BeTrue())
+			blocked := provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerProvisioningBlocked)
+			Expect(blocked.IsTrue()).To(BeTrue())
+			Expect(blocked.Message).To(Equal("insufficient capacity"))
+
+			// the failure no longer marks the cloud provider unhealthy once it is old enough
+			fakeClock.Step(15 * time.Minute)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerCloudProviderHealthy).IsTrue()).To(BeTrue())
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerProvisioningBlocked).IsFalse()).To(BeTrue())
+		})
+		It("should not be blocked once a launch succeeds after a failure", func() {
+			ExpectApplied(ctx, env.Client, provisioner)
+			cluster.RecordLaunch(provisioner.Name, fmt.Errorf("insufficient capacity"))
+			fakeClock.Step(time.Second)
+			cluster.RecordLaunch(provisioner.Name, nil)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerCloudProviderHealthy).IsTrue()).To(BeTrue())
+		})
+		It("should be blocked while the circuit breaker is open", func() {
+			ExpectApplied(ctx, env.Client, provisioner)
+			circuitBreaker.Record(ctx, provisioner.Name, cloudprovider.NewRetryableError(fmt.Errorf("service unavailable")))
+			circuitBreaker.Record(ctx, provisioner.Name, cloudprovider.NewRetryableError(fmt.Errorf("service unavailable")))
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerCloudProviderHealthy).IsFalse()).To(BeTrue())
+			blocked := provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerProvisioningBlocked)
+			Expect(blocked.IsTrue()).To(BeTrue())
+			Expect(blocked.Reason).To(Equal("CircuitOpen"))
+			Expect(blocked.Message).To(ContainSubstring("service unavailable"))
+
+			circuitBreaker.Record(ctx, provisioner.Name, nil)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerCloudProviderHealthy).IsTrue()).To(BeTrue())
+		})
+		It("should be paused and blocked when the provisioner is paused", func() {
+			provisioner.Spec.Paused = true
+			ExpectApplied(ctx, env.Client, provisioner)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerPaused).IsTrue()).To(BeTrue())
+			blocked := provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerProvisioningBlocked)
+			Expect(blocked.IsTrue()).To(BeTrue())
+			Expect(blocked.Reason).To(Equal("Paused"))
+			Expect(recorder.Events).To(Receive(ContainSubstring("Paused")))
+		})
+		It("should publish an event when the provisioner is resumed", func() {
+			provisioner.Spec.Paused = true
+			ExpectApplied(ctx, env.Client, provisioner)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			Expect(recorder.Events).To(Receive(ContainSubstring("Paused")))
+
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			provisioner.Spec.Paused = false
+			ExpectApplied(ctx, env.Client, provisioner)
+			ExpectReconcileSucceeded(ctx, counterController, client.ObjectKeyFromObject(provisioner))
+			provisioner = ExpectExists(ctx, env.Client, provisioner)
+			Expect(provisioner.StatusConditions().GetCondition(v1alpha5.ProvisionerPaused).IsFalse()).To(BeTrue())
+			Expect(recorder.Events).To(Receive(ContainSubstring("Resumed")))
+		})
+	})
+})
@@ -0,0 +1,175 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deprovisioning_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/test"
+	. "github.com/aws/karpenter-core/pkg/test/expectations"
+)
+
+var _ = Describe("Disruption Budgets", func() {
+	var prov *v1alpha5.Provisioner
+	var nodes []*v1.Node
+
+	makeNodes := func(count int, annotations map[string]string) []*v1.Node {
+		var ret []*v1.Node
+		for i := 0; i < count; i++ {
+			ret = append(ret, test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1alpha5.ProvisionerNameLabelKey: prov.Name,
+						v1.LabelInstanceTypeStable:       mostExpensiveInstance.Name,
+						v1alpha5.LabelCapacityType:       mostExpensiveOffering.CapacityType,
+						v1.LabelTopologyZone:             mostExpensiveOffering.Zone,
+					},
+					Annotations: annotations,
+				},
+				Allocatable: map[v1.ResourceName]resource.Quantity{
+					v1.ResourceCPU:  resource.MustParse("32"),
+					v1.ResourcePods: resource.MustParse("100"),
+				}}))
+		}
+		return ret
+	}
+	applyNodes := func() {
+		ExpectApplied(ctx, env.Client, prov)
+		for _, n := range nodes {
+			ExpectApplied(ctx, env.Client, n)
+		}
+		ExpectMakeNodesReady(ctx, env.Client, nodes...)
+		// inform cluster state about the nodes
+		for _, n := range nodes {
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(n))
+		}
+	}
+	expectDeleted := func(count int) {
+		deleted := 0
+		for _, n := range nodes {
+			if err := env.Client.Get(ctx, client.ObjectKeyFromObject(n), &v1.Node{}); err != nil {
+				deleted++
+			}
+		}
+		Expect(deleted).To(Equal(count))
+	}
+
+	It("should only delete as many empty nodes as an absolute budget allows with consolidation", func() {
+		prov = test.Provisioner(test.ProvisionerOptions{
+			Consolidation: &v1alpha5.Consolidation{Enabled: ptr.Bool(true)},
+			Disruption:    &v1alpha5.Disruption{Budgets: []v1alpha5.Budget{{Nodes: "1"}}},
+		})
+		nodes = makeNodes(3, nil)
+		applyNodes()
+
+		fakeClock.Step(10 * time.Minute)
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+		expectDeleted(1)
+	})
+	It("should only delete as many empty nodes as a percentage budget allows with TTLSecondsAfterEmpty", func() {
+		prov = test.Provisioner(test.ProvisionerOptions{
+			TTLSecondsAfterEmpty: ptr.Int64(10),
+			Disruption:           &v1alpha5.Disruption{Budgets: []v1alpha5.Budget{{Nodes: "50%"}}},
+		})
+		nodes = makeNodes(3, map[string]string{
+			v1alpha5.EmptinessTimestampAnnotationKey: fakeClock.Now().Format(time.RFC3339),
+		})
+		applyNodes()
+
+		fakeClock.Step(10 * time.Minute)
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+
+		// 50% of 3 nodes rounds up to 2 nodes
+		expectDeleted(2)
+	})
+	It("should use the most restrictive budget", func() {
+		prov = test.Provisioner(test.ProvisionerOptions{
+			TTLSecondsAfterEmpty: ptr.Int64(10),
+			Disruption:           &v1alpha5.Disruption{Budgets: []v1alpha5.Budget{{Nodes: "100%"}, {Nodes: "1"}}},
+		})
+		nodes = makeNodes(3, map[string]string{
+			v1alpha5.EmptinessTimestampAnnotationKey: fakeClock.Now().Format(time.RFC3339),
+		})
+		applyNodes()
+
+		fakeClock.Step(10 * time.Minute)
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+
+		expectDeleted(1)
+	})
+	It("should not expire nodes when the budget is zero", func() {
+		prov = test.Provisioner(test.ProvisionerOptions{
+			TTLSecondsUntilExpired: ptr.Int64(30),
+			Disruption:             &v1alpha5.Disruption{Budgets: []v1alpha5.Budget{{Nodes: "0"}}},
+		})
+		nodes = makeNodes(1, nil)
+		applyNodes()
+
+		fakeClock.Step(10 * time.Minute)
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+		ExpectNodeExists(ctx, env.Client, nodes[0].Name)
+	})
+	It("should not drift nodes when other nodes of the provisioner are already being disrupted", func() {
+		prov = test.Provisioner(test.ProvisionerOptions{
+			Disruption: &v1alpha5.Disruption{Budgets: []v1alpha5.Budget{{Nodes: "1"}}},
+		})
+		nodes = makeNodes(2, map[string]string{
+			v1alpha5.VoluntaryDisruptionAnnotationKey: v1alpha5.VoluntaryDisruptionDriftedAnnotationValue,
+		})
+		applyNodes()
+		cluster.MarkForDeletion(nodes[0].Name)
+
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+		ExpectNodeExists(ctx, env.Client, nodes[1].Name)
+		cluster.UnmarkForDeletion(nodes[0].Name)
+	})
+	It("should not limit disruption when no budgets are defined", func() {
+		prov = test.Provisioner(test.ProvisionerOptions{
+			TTLSecondsAfterEmpty: ptr.Int64(10),
+		})
+		nodes = makeNodes(3, map[string]string{
+			v1alpha5.EmptinessTimestampAnnotationKey: fakeClock.Now().Format(time.RFC3339),
+		})
+		applyNodes()
+
+		fakeClock.Step(10 * time.Minute)
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+
+		expectDeleted(3)
+	})
+})
@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"time"
 
@@ -33,6 +34,7 @@ import (
 	"github.com/aws/karpenter-core/pkg/controllers/state"
 	"github.com/aws/karpenter-core/pkg/metrics"
 	"github.com/aws/karpenter-core/pkg/scheduling"
+	"github.com/aws/karpenter-core/pkg/utils/resources"
 )
 
 // consolidation is the base consolidation controller that provides common functionality used across the different
@@ -92,7 +94,9 @@ func (c *consolidation) sortAndFilterCandidates(ctx context.Context, nodes []Can
 }
 
 // ShouldDeprovision is a predicate used to filter deprovisionable nodes
-func (c *consolidation) ShouldDeprovision(ctx context.Context, n *state.Node, provisioner *v1alpha5.Provisioner, _ []*v1.Pod) bool {
+//
+//nolint:gocyclo
+func (c *consolidation) ShouldDeprovision(ctx context.Context, n *state.Node, provisioner *v1alpha5.Provisioner, nodePods []*v1.Pod) bool {
 	if val, ok := n.Annotations()[v1alpha5.DoNotConsolidateNodeAnnotationKey]; ok {
 		c.reporter.RecordUnconsolidatableReason(ctx, n.Node, fmt.Sprintf("%s annotation exists", v1alpha5.DoNotConsolidateNodeAnnotationKey))
 		return val != "true"
@@ -105,6 +109,21 @@ func (c *consolidation) ShouldDeprovision(ctx context.Context, n *state.Node, pr
 		c.reporter.RecordUnconsolidatableReason(ctx, n.Node, fmt.Sprintf("provisioner %s has consolidation disabled", provisioner.Name))
 		return false
 	}
+	if !inMaintenanceWindow(c.clock, provisioner) {
+		c.reporter.RecordUnconsolidatableReason(ctx, n.Node, fmt.Sprintf("provisioner %s is outside of its maintenance windows", provisioner.Name))
+		return false
+	}
+	if provisioner.Spec.Consolidation.Policy == v1alpha5.ConsolidationPolicyWhenEmpty && len(nodePods) != 0 {
+		c.reporter.RecordUnconsolidatableReason(ctx, n.Node, fmt.Sprintf("provisioner %s only consolidates empty nodes", provisioner.Name))
+		return false
+	}
+	if threshold := provisioner.Spec.Consolidation.UtilizationThreshold; threshold != nil {
+		if utilization := nodeUtilization(n, nodePods); utilization > float64(*threshold)/100 {
+			c.reporter.RecordUnconsolidatableReason(ctx, n.Node, fmt.Sprintf("node is %.0f%% utilized which is above the utilization threshold of %d%%",
+				utilization*100, *threshold))
+			return false
+		}
+	}
 	return true
 }
 
@@ -212,16 +231,47 @@ func (c *consolidation) computeConsolidation(ctx context.Context, nodes ...Candi
 		return Command{action: actionDoNothing}, nil
 	}
 
+	// we can't tell whether a replacement is cheaper without prices
+	if !c.cloudProvider.Capabilities().Pricing {
+		if len(nodes) == 1 {
+			c.reporter.RecordUnconsolidatableReason(ctx, nodes[0].Node, fmt.Sprintf("can't replace, cloud provider %s doesn't report prices", c.cloudProvider.Name()))
+		}
+		return Command{action: actionDoNothing}, nil
+	}
+
+	// Reserved capacity is paid for whether it is used or not, so we only replace reserved nodes with reserved nodes.
+	// Otherwise, consolidating a reserved node with other nodes would move its pods to capacity that we pay for again.
+	if lo.ContainsBy(nodes, func(n CandidateNode) bool { return n.capacityType == v1alpha5.CapacityTypeReserved }) {
+		if !newNodes[0].Requirements.Get(v1alpha5.LabelCapacityType).Has(v1alpha5.CapacityTypeReserved) {
+			if len(nodes) == 1 {
+				c.reporter.RecordUnconsolidatableReason(ctx, nodes[0].Node, "can't replace a reserved node with a node that isn't reserved")
+			}
+			return Command{action: actionDoNothing}, nil
+		}
+		newNodes[0].Requirements.Add(scheduling.NewRequirement(v1alpha5.LabelCapacityType, v1.NodeSelectorOpIn, v1alpha5.CapacityTypeReserved))
+	}
+
 	// get the current node price based on the offering
 	// fallback if we can't find the specific zonal pricing data
 	nodesPrice, err := getNodePrices(nodes)
 	if err != nil {
 		return Command{}, fmt.Errorf("getting offering price from candidate node, %w", err)
 	}
-	newNodes[0].InstanceTypeOptions = filterByPrice(newNodes[0].InstanceTypeOptions, newNodes[0].Requirements, nodesPrice)
+	savings := minimumSavings(nodes)
+	newNodes[0].InstanceTypeOptions, err = filterByPrice(newNodes[0].InstanceTypeOptions, newNodes[0].Requirements, newNodes[0].MinValues, nodesPrice, savings)
+	if err != nil {
+		if len(nodes) == 1 {
+			c.reporter.RecordUnconsolidatableReason(ctx, nodes[0].Node, fmt.Sprintf("can't replace with a cheaper node that is flexible enough, %s", err))
+		}
+		return Command{action: actionDoNothing}, nil
+	}
 	if len(newNodes[0].InstanceTypeOptions) == 0 {
 		if len(nodes) == 1 {
-			c.reporter.RecordUnconsolidatableReason(ctx, nodes[0].Node, "can't replace with a cheaper node")
+			if savings > 0 {
+				c.reporter.RecordUnconsolidatableReason(ctx, nodes[0].Node, fmt.Sprintf("can't replace with a node that is at least %.0f%% cheaper", savings*100))
+			} else {
+				c.reporter.RecordUnconsolidatableReason(ctx, nodes[0].Node, "can't replace with a cheaper node")
+			}
 		}
 		// no instance types remain after filtering by price
 		return Command{action: actionDoNothing}, nil
@@ -248,10 +298,15 @@ func (c *consolidation) computeConsolidation(ctx context.Context, nodes ...Candi
 	// We are consolidating a node from OD -> [OD,Spot] but have filtered the instance types by cost based on the
 	// assumption, that the spot variant will launch. We also need to add a requirement to the node to ensure that if
 	// spot capacity is insufficient we don't replace the node with a more expensive on-demand node.  Instead the launch
-	// should fail and we'll just leave the node alone.
+	// should fail and we'll just leave the node alone. Cloud providers that can't launch spot would always fail.
 	ctReq := newNodes[0].Requirements.Get(v1alpha5.LabelCapacityType)
-	if ctReq.Has(v1alpha5.CapacityTypeSpot) && ctReq.Has(v1alpha5.CapacityTypeOnDemand) {
-		newNodes[0].Requirements.Add(scheduling.NewRequirement(v1alpha5.LabelCapacityType, v1.NodeSelectorOpIn, v1alpha5.CapacityTypeSpot))
+	// Reserved capacity is cheaper still, so the replacement can be launched into a reservation as well.
+	if c.cloudProvider.Capabilities().Spot && ctReq.Has(v1alpha5.CapacityTypeSpot) && ctReq.Has(v1alpha5.CapacityTypeOnDemand) {
+		capacityTypes := []string{v1alpha5.CapacityTypeSpot}
+		if ctReq.Has(v1alpha5.CapacityTypeReserved) {
+			capacityTypes = append(capacityTypes, v1alpha5.CapacityTypeReserved)
+		}
+		newNodes[0].Requirements.Add(scheduling.NewRequirement(v1alpha5.LabelCapacityType, v1.NodeSelectorOpIn, capacityTypes...))
 	}
 
 	return Command{
@@ -261,15 +316,57 @@ func (c *consolidation) computeConsolidation(ctx context.Context, nodes ...Candi
 	}, nil
 }
 
+// minimumSavings returns the fraction of the price of the given candidate nodes that a replacement has to save. If the
+// candidate nodes belong to different provisioners, the largest minimum savings is used.
+func minimumSavings(nodes []CandidateNode) float64 {
+	var savings float64
+	for _, n := range nodes {
+		if n.provisioner.Spec.Consolidation == nil || n.provisioner.Spec.Consolidation.MinimumSavingsPercent == nil {
+			continue
+		}
+		savings = math.Max(savings, float64(*n.provisioner.Spec.Consolidation.MinimumSavingsPercent)/100)
+	}
+	return savings
+}
+
+// nodeUtilization returns the highest fraction of the node's allocatable CPU or memory that is requested by the pods.
+// Daemonset pods aren't passed in, the same as when deciding whether a node is empty, so a node running only
+// daemonsets isn't utilized at all.
+func nodeUtilization(n *state.Node, pods []*v1.Pod) float64 {
+	var utilization float64
+	requests := resources.RequestsForPods(pods...)
+	allocatable := n.Allocatable()
+	for _, resourceName := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		total, ok := allocatable[resourceName]
+		if !ok || total.IsZero() {
+			continue
+		}
+		requested := requests[resourceName]
+		utilization = math.Max(utilization, requested.AsApproximateFloat64()/total.AsApproximateFloat64())
+	}
+	return utilization
+}
+
+// offering returns the offering that the node was launched with, which is the offering that is compatible with the
+// labels of the node. If the labels don't tell offerings apart, e.g. a reservation that the node isn't labeled with,
+// the cheapest of them is used.
+func (n CandidateNode) offering() (cloudprovider.Offering, bool) {
+	offerings := n.instanceType.Offerings.Requirements(scheduling.NewLabelRequirements(n.Labels))
+	if len(offerings) == 0 {
+		return cloudprovider.Offering{}, false
+	}
+	return offerings.Cheapest(), true
+}
+
 // getNodePrices returns the sum of the prices of the given candidate nodes
 func getNodePrices(nodes []CandidateNode) (float64, error) {
 	var price float64
 	for _, n := range nodes {
-		offering, ok := n.instanceType.Offerings.Get(n.capacityType, n.zone)
+		offering, ok := n.offering()
 		if !ok {
 			return 0.0, fmt.Errorf("unable to determine offering for %s/%s/%s", n.instanceType.Name, n.capacityType, n.zone)
 		}
-		price += offering.Price
+		price += offering.EffectivePrice()
 	}
 	return price, nil
 }
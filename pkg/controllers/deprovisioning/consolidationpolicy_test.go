@@ -0,0 +1,261 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deprovisioning_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+	"github.com/aws/karpenter-core/pkg/test"
+	. "github.com/aws/karpenter-core/pkg/test/expectations"
+)
+
+var _ = Describe("Consolidation Policy", func() {
+	var currentInstance *cloudprovider.InstanceType
+	var rs *appsv1.ReplicaSet
+
+	BeforeEach(func() {
+		currentInstance = fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name: "current-on-demand",
+			Offerings: []cloudprovider.Offering{
+				{
+					CapacityType: v1alpha5.CapacityTypeOnDemand,
+					Zone:         "test-zone-1a",
+					Price:        1.0,
+					Available:    true,
+				},
+			},
+			Resources: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("8")},
+		})
+		// the replacement is 10% cheaper than the current instance type
+		replacementInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name: "replacement-on-demand",
+			Offerings: []cloudprovider.Offering{
+				{
+					CapacityType: v1alpha5.CapacityTypeOnDemand,
+					Zone:         "test-zone-1a",
+					Price:        0.9,
+					Available:    true,
+				},
+			},
+			Resources: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("4")},
+		})
+		cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{currentInstance, replacementInstance}
+
+		// create our RS so we can link a pod to it
+		rs = test.ReplicaSet()
+		ExpectApplied(ctx, env.Client, rs)
+		Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+	})
+	// setup creates a node of the current instance type with a single pod requesting the given CPU bound to it
+	setup := func(consolidation *v1alpha5.Consolidation, cpu string) *v1.Node {
+		pod := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{"app": "test"},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion:         "apps/v1",
+						Kind:               "ReplicaSet",
+						Name:               rs.Name,
+						UID:                rs.UID,
+						Controller:         ptr.Bool(true),
+						BlockOwnerDeletion: ptr.Bool(true),
+					},
+				}},
+			ResourceRequirements: v1.ResourceRequirements{
+				Requests: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse(cpu)},
+			},
+		})
+		prov := test.Provisioner(test.ProvisionerOptions{Consolidation: consolidation})
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1alpha5.ProvisionerNameLabelKey: prov.Name,
+					v1.LabelInstanceTypeStable:       currentInstance.Name,
+					v1alpha5.LabelCapacityType:       currentInstance.Offerings[0].CapacityType,
+					v1.LabelTopologyZone:             currentInstance.Offerings[0].Zone,
+				}},
+			Allocatable: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("8")},
+		})
+		ExpectApplied(ctx, env.Client, pod, node, prov)
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectScheduled(ctx, env.Client, pod)
+		Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(node), node)).To(Succeed())
+		return node
+	}
+
+	It("should replace a node with a cheaper one when underutilized", func() {
+		node := setup(&v1alpha5.Consolidation{
+			Enabled: ptr.Bool(true),
+			Policy:  v1alpha5.ConsolidationPolicyWhenUnderutilized,
+		}, "1")
+
+		wg := ExpectMakeNewNodesReady(ctx, env.Client, 1, node)
+		fakeClock.Step(10 * time.Minute)
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		wg.Wait()
+
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		ExpectNotFound(ctx, env.Client, node)
+	})
+	It("should not replace non-empty nodes with the WhenEmpty policy", func() {
+		node := setup(&v1alpha5.Consolidation{
+			Enabled: ptr.Bool(true),
+			Policy:  v1alpha5.ConsolidationPolicyWhenEmpty,
+		}, "1")
+
+		fakeClock.Step(10 * time.Minute)
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+		ExpectNodeExists(ctx, env.Client, node.Name)
+	})
+	It("should not replace nodes that are utilized above the utilization threshold", func() {
+		// 3 of 8 CPUs is 37.5% utilization
+		node := setup(&v1alpha5.Consolidation{
+			Enabled:              ptr.Bool(true),
+			UtilizationThreshold: ptr.Int32(25),
+		}, "3")
+
+		fakeClock.Step(10 * time.Minute)
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+		ExpectNodeExists(ctx, env.Client, node.Name)
+	})
+	It("should replace nodes that are utilized below the utilization threshold", func() {
+		node := setup(&v1alpha5.Consolidation{
+			Enabled:              ptr.Bool(true),
+			UtilizationThreshold: ptr.Int32(50),
+		}, "3")
+
+		wg := ExpectMakeNewNodesReady(ctx, env.Client, 1, node)
+		fakeClock.Step(10 * time.Minute)
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		wg.Wait()
+
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		ExpectNotFound(ctx, env.Client, node)
+	})
+	It("should not count daemonset pods towards the utilization threshold", func() {
+		node := setup(&v1alpha5.Consolidation{
+			Enabled:              ptr.Bool(true),
+			UtilizationThreshold: ptr.Int32(50),
+		}, "1")
+		// 7 of 8 CPUs are requested, but only 1 of them by pods that aren't daemonset pods
+		ds := test.DaemonSet()
+		ExpectApplied(ctx, env.Client, ds)
+		Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(ds), ds)).To(Succeed())
+		dsPod := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion:         "apps/v1",
+						Kind:               "DaemonSet",
+						Name:               ds.Name,
+						UID:                ds.UID,
+						Controller:         ptr.Bool(true),
+						BlockOwnerDeletion: ptr.Bool(true),
+					},
+				}},
+			ResourceRequirements: v1.ResourceRequirements{
+				Requests: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("6")},
+			},
+		})
+		ExpectApplied(ctx, env.Client, dsPod)
+		ExpectManualBinding(ctx, env.Client, dsPod, node)
+
+		wg := ExpectMakeNewNodesReady(ctx, env.Client, 1, node)
+		fakeClock.Step(10 * time.Minute)
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		wg.Wait()
+
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		ExpectNotFound(ctx, env.Client, node)
+	})
+	It("should not replace a node if the replacement doesn't save the minimum savings", func() {
+		node := setup(&v1alpha5.Consolidation{
+			Enabled:               ptr.Bool(true),
+			MinimumSavingsPercent: ptr.Int32(20),
+		}, "1")
+
+		fakeClock.Step(10 * time.Minute)
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+		ExpectNodeExists(ctx, env.Client, node.Name)
+	})
+	It("should replace a node if the replacement saves the minimum savings", func() {
+		node := setup(&v1alpha5.Consolidation{
+			Enabled:               ptr.Bool(true),
+			MinimumSavingsPercent: ptr.Int32(5),
+		}, "1")
+
+		wg := ExpectMakeNewNodesReady(ctx, env.Client, 1, node)
+		fakeClock.Step(10 * time.Minute)
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		wg.Wait()
+
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		ExpectNotFound(ctx, env.Client, node)
+	})
+	It("should not replace a node if the cheaper replacements are narrower than the minimum values", func() {
+		node := setup(&v1alpha5.Consolidation{Enabled: ptr.Bool(true)}, "1")
+		// both instance types can run the pod, but only the replacement instance type is cheaper
+		provisioner := &v1alpha5.Provisioner{}
+		Expect(env.Client.Get(ctx, client.ObjectKey{Name: node.Labels[v1alpha5.ProvisionerNameLabelKey]}, provisioner)).To(Succeed())
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpExists}, MinValues: lo.ToPtr(2)},
+		}
+		ExpectApplied(ctx, env.Client, provisioner)
+
+		fakeClock.Step(10 * time.Minute)
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+		ExpectNodeExists(ctx, env.Client, node.Name)
+	})
+})
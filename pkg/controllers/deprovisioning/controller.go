@@ -86,10 +86,10 @@ func NewController(clk clock.Clock, kubeClient client.Client, provisioner *provi
 			// Expire any nodes that must be deleted, allowing their pods to potentially land on currently
 			NewExpiration(clk, kubeClient, cluster, provisioner),
 			// Terminate any nodes that have drifted from provisioning specifications, allowing the pods to reschedule.
-			NewDrift(kubeClient, cluster, provisioner),
+			NewDrift(clk, kubeClient, cluster, provisioner),
 			// Delete any remaining empty nodes as there is zero cost in terms of dirsuption.  Emptiness and
 			// emptyNodeConsolidation are mutually exclusive, only one of these will operate
-			NewEmptiness(clk),
+			NewEmptiness(clk, cluster),
 			NewEmptyNodeConsolidation(clk, cluster, kubeClient, provisioner, cp, reporter),
 			// Attempt to identify multiple nodes that we can consolidate simultaneously to reduce pod churn
 			NewMultiNodeConsolidation(clk, cluster, kubeClient, provisioner, cp, reporter),
@@ -108,6 +108,11 @@ func (c *Controller) Builder(_ context.Context, m manager.Manager) controller.Bu
 }
 
 func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	// Determine how many more nodes each provisioner allows to be disrupted
+	budgets, err := buildDisruptionBudgets(ctx, c.cluster, c.kubeClient)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("building disruption budgets, %w", err)
+	}
 	// Attempt different deprovisioning methods. We'll only let one method perform an action
 	for _, d := range c.deprovisioners {
 		candidates, err := candidateNodes(ctx, c.cluster, c.kubeClient, c.clock, c.cloudProvider, d.ShouldDeprovision)
@@ -120,7 +125,7 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 		}
 
 		// Determine the deprovisioning action
-		cmd, err := d.ComputeCommand(ctx, candidates...)
+		cmd, err := d.ComputeCommand(ctx, budgets, candidates...)
 		if err != nil {
 			return reconcile.Result{}, fmt.Errorf("computing deprovisioning decision, %w", err)
 		}
@@ -206,20 +211,15 @@ func (c *Controller) launchReplacementNodes(ctx context.Context, action Command)
 		return fmt.Errorf("cordoning nodes, %w", err)
 	}
 
-	nodeNames, err := c.provisioner.LaunchMachines(ctx, action.replacementNodes)
+	// the old nodes are marked for deletion once we have the new nodes created at the API server
+	nodeNames, err := c.provisioner.LaunchReplacements(ctx, action.replacementNodes, nodeNamesToRemove)
 	if err != nil {
 		// uncordon the nodes as the launch may fail (e.g. ICE or incompatible AMI)
 		err = multierr.Append(err, c.setNodesUnschedulable(ctx, false, nodeNamesToRemove...))
 		return err
 	}
-	if len(nodeNames) != len(action.replacementNodes) {
-		// shouldn't ever occur since a partially failed LaunchMachines should return an error
-		return fmt.Errorf("expected %d node names, got %d", len(action.replacementNodes), len(nodeNames))
-	}
 	metrics.NodesCreatedCounter.WithLabelValues(metrics.DeprovisioningReason).Add(float64(len(nodeNames)))
 
-	// We have the new nodes created at the API server so mark the old nodes for deletion
-	c.cluster.MarkForDeletion(nodeNamesToRemove...)
 	// Wait for nodes to be ready
 	// TODO @njtran: Allow to bypass this check for certain deprovisioners
 	errs := make([]error, len(nodeNames))
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
 	"knative.dev/pkg/logging"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -33,13 +34,15 @@ import (
 // Drift is a subreconciler that deletes empty nodes.
 // Drift will respect TTLSecondsAfterEmpty
 type Drift struct {
+	clock       clock.Clock
 	kubeClient  client.Client
 	cluster     *state.Cluster
 	provisioner *provisioning.Provisioner
 }
 
-func NewDrift(kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner) *Drift {
+func NewDrift(clk clock.Clock, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner) *Drift {
 	return &Drift{
+		clock:       clk,
 		kubeClient:  kubeClient,
 		cluster:     cluster,
 		provisioner: provisioner,
@@ -52,16 +55,21 @@ func (d *Drift) ShouldDeprovision(ctx context.Context, n *state.Node, provisione
 	if !settings.FromContext(ctx).DriftEnabled {
 		return false
 	}
-	return n.Annotations()[v1alpha5.VoluntaryDisruptionAnnotationKey] == v1alpha5.VoluntaryDisruptionDriftedAnnotationValue
+	return n.Annotations()[v1alpha5.VoluntaryDisruptionAnnotationKey] == v1alpha5.VoluntaryDisruptionDriftedAnnotationValue &&
+		inMaintenanceWindow(d.clock, provisioner)
 }
 
 // ComputeCommand generates a deprovisioning command given deprovisionable nodes
-func (d *Drift) ComputeCommand(ctx context.Context, candidates ...CandidateNode) (Command, error) {
+func (d *Drift) ComputeCommand(ctx context.Context, budgets map[string]int, candidates ...CandidateNode) (Command, error) {
 	pdbs, err := NewPDBLimits(ctx, d.kubeClient)
 	if err != nil {
 		return Command{}, fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
 	}
 	for _, candidate := range candidates {
+		// skip the node if its provisioner doesn't allow any more nodes to be disrupted
+		if budgets[candidate.provisioner.Name] == 0 {
+			continue
+		}
 		// is this a node that we can terminate?  This check is meant to be fast so we can save the expense of simulated
 		// scheduling unless its really needed
 		if _, canTerminate := canBeTerminated(candidate, pdbs); !canTerminate {
@@ -34,12 +34,14 @@ import (
 // Emptiness is a subreconciler that deletes empty nodes.
 // Emptiness will respect TTLSecondsAfterEmpty
 type Emptiness struct {
-	clock clock.Clock
+	clock   clock.Clock
+	cluster *state.Cluster
 }
 
-func NewEmptiness(clk clock.Clock) *Emptiness {
+func NewEmptiness(clk clock.Clock, cluster *state.Cluster) *Emptiness {
 	return &Emptiness{
-		clock: clk,
+		clock:   clk,
+		cluster: cluster,
 	}
 }
 
@@ -65,8 +67,10 @@ func (e *Emptiness) ShouldDeprovision(ctx context.Context, n *state.Node, provis
 }
 
 // ComputeCommand generates a deprovisioning command given deprovisionable nodes
-func (e *Emptiness) ComputeCommand(_ context.Context, nodes ...CandidateNode) (Command, error) {
+func (e *Emptiness) ComputeCommand(_ context.Context, budgets map[string]int, nodes ...CandidateNode) (Command, error) {
 	emptyNodes := lo.Filter(nodes, func(n CandidateNode, _ int) bool { return len(n.pods) == 0 })
+	emptyNodes = filterByDisruptionBudgets(emptyNodes, budgets)
+	emptyNodes = filterByMinimumCapacity(e.cluster, emptyNodes)
 	if len(emptyNodes) == 0 {
 		return Command{action: actionDoNothing}, nil
 	}
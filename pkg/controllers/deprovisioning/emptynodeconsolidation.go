@@ -41,7 +41,7 @@ func NewEmptyNodeConsolidation(clk clock.Clock, cluster *state.Cluster, kubeClie
 }
 
 // ComputeCommand generates a deprovisioning command given deprovisionable nodes
-func (c *EmptyNodeConsolidation) ComputeCommand(ctx context.Context, candidates ...CandidateNode) (Command, error) {
+func (c *EmptyNodeConsolidation) ComputeCommand(ctx context.Context, budgets map[string]int, candidates ...CandidateNode) (Command, error) {
 	if c.cluster.Consolidated() {
 		return Command{action: actionDoNothing}, nil
 	}
@@ -52,6 +52,8 @@ func (c *EmptyNodeConsolidation) ComputeCommand(ctx context.Context, candidates
 
 	// select the entirely empty nodes
 	emptyNodes := lo.Filter(candidates, func(n CandidateNode, _ int) bool { return len(n.pods) == 0 })
+	emptyNodes = filterByDisruptionBudgets(emptyNodes, budgets)
+	emptyNodes = filterByMinimumCapacity(c.cluster, emptyNodes)
 	if len(emptyNodes) == 0 {
 		return Command{action: actionDoNothing}, nil
 	}
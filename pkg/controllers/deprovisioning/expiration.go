@@ -32,6 +32,7 @@ import (
 	"github.com/aws/karpenter-core/pkg/controllers/provisioning"
 	"github.com/aws/karpenter-core/pkg/controllers/state"
 	"github.com/aws/karpenter-core/pkg/metrics"
+	nodeutils "github.com/aws/karpenter-core/pkg/utils/node"
 )
 
 // Expiration is a subreconciler that deletes empty nodes.
@@ -54,25 +55,29 @@ func NewExpiration(clk clock.Clock, kubeClient client.Client, cluster *state.Clu
 
 // ShouldDeprovision is a predicate used to filter deprovisionable nodes
 func (e *Expiration) ShouldDeprovision(ctx context.Context, n *state.Node, provisioner *v1alpha5.Provisioner, nodePods []*v1.Pod) bool {
-	return e.clock.Now().After(getExpirationTime(n.Node, provisioner))
+	return e.clock.Now().After(nodeutils.GetExpirationTime(n.Node, provisioner)) && inMaintenanceWindow(e.clock, provisioner)
 }
 
 // SortCandidates orders expired nodes by when they've expired
 func (e *Expiration) SortCandidates(nodes []CandidateNode) []CandidateNode {
 	sort.Slice(nodes, func(i int, j int) bool {
-		return getExpirationTime(nodes[i].Node, nodes[i].provisioner).Before(getExpirationTime(nodes[j].Node, nodes[j].provisioner))
+		return nodeutils.GetExpirationTime(nodes[i].Node, nodes[i].provisioner).Before(nodeutils.GetExpirationTime(nodes[j].Node, nodes[j].provisioner))
 	})
 	return nodes
 }
 
 // ComputeCommand generates a deprovisioning command given deprovisionable nodes
-func (e *Expiration) ComputeCommand(ctx context.Context, candidates ...CandidateNode) (Command, error) {
+func (e *Expiration) ComputeCommand(ctx context.Context, budgets map[string]int, candidates ...CandidateNode) (Command, error) {
 	candidates = e.SortCandidates(candidates)
 	pdbs, err := NewPDBLimits(ctx, e.kubeClient)
 	if err != nil {
 		return Command{}, fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
 	}
 	for _, candidate := range candidates {
+		// skip the node if its provisioner doesn't allow any more nodes to be disrupted
+		if budgets[candidate.provisioner.Name] == 0 {
+			continue
+		}
 		// is this a node that we can terminate?  This check is meant to be fast so we can save the expense of simulated
 		// scheduling unless its really needed
 		if _, canBeTerminated := canBeTerminated(candidate, pdbs); !canBeTerminated {
@@ -94,7 +99,7 @@ func (e *Expiration) ComputeCommand(ctx context.Context, candidates ...Candidate
 		}
 
 		logging.FromContext(ctx).With("expirationTTL", time.Duration(ptr.Int64Value(candidates[0].provisioner.Spec.TTLSecondsUntilExpired))*time.Second).
-			With("delay", time.Since(getExpirationTime(candidates[0].Node, candidates[0].provisioner))).Infof("triggering termination for expired node after TTL")
+			With("delay", time.Since(nodeutils.GetExpirationTime(candidates[0].Node, candidates[0].provisioner))).Infof("triggering termination for expired node after TTL")
 
 		// were we able to schedule all the pods on the inflight nodes?
 		if len(newNodes) == 0 {
@@ -116,12 +121,3 @@ func (e *Expiration) ComputeCommand(ctx context.Context, candidates ...Candidate
 func (e *Expiration) String() string {
 	return metrics.ExpirationReason
 }
-
-func getExpirationTime(node *v1.Node, provisioner *v1alpha5.Provisioner) time.Time {
-	if provisioner == nil || provisioner.Spec.TTLSecondsUntilExpired == nil {
-		// If not defined, return some much larger time.
-		return time.Date(5000, 0, 0, 0, 0, 0, 0, time.UTC)
-	}
-	expirationTTL := time.Duration(ptr.Int64Value(provisioner.Spec.TTLSecondsUntilExpired)) * time.Second
-	return node.CreationTimestamp.Add(expirationTTL)
-}
@@ -31,6 +31,7 @@ import (
 	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
 	"github.com/aws/karpenter-core/pkg/test"
 	. "github.com/aws/karpenter-core/pkg/test/expectations"
+	nodeutils "github.com/aws/karpenter-core/pkg/utils/node"
 )
 
 var _ = Describe("Expiration", func() {
@@ -96,6 +97,45 @@ var _ = Describe("Expiration", func() {
 		// and delete the old one
 		ExpectNotFound(ctx, env.Client, node)
 	})
+	It("should delay expiration by the node's jitter offset", func() {
+		prov := test.Provisioner(test.ProvisionerOptions{
+			TTLSecondsUntilExpired:       ptr.Int64(60),
+			TTLSecondsUntilExpiredJitter: ptr.Int64(3600),
+		})
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1alpha5.ProvisionerNameLabelKey: prov.Name,
+					v1.LabelInstanceTypeStable:       mostExpensiveInstance.Name,
+					v1alpha5.LabelCapacityType:       mostExpensiveOffering.CapacityType,
+					v1.LabelTopologyZone:             mostExpensiveOffering.Zone,
+				}},
+			Allocatable: map[v1.ResourceName]resource.Quantity{
+				v1.ResourceCPU:  resource.MustParse("32"),
+				v1.ResourcePods: resource.MustParse("100"),
+			}},
+		)
+
+		ExpectApplied(ctx, env.Client, node, prov)
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		node = ExpectNodeExists(ctx, env.Client, node.Name)
+		expirationTime := nodeutils.GetExpirationTime(node, prov)
+		Expect(expirationTime).To(BeTemporally(">=", node.CreationTimestamp.Add(time.Minute)))
+		Expect(expirationTime).To(BeTemporally("<", node.CreationTimestamp.Add(time.Minute+time.Hour)))
+
+		// inform cluster state about the nodes
+		ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+		fakeClock.SetTime(expirationTime.Add(-time.Second))
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		ExpectNodeExists(ctx, env.Client, node.Name)
+
+		fakeClock.SetTime(expirationTime.Add(time.Second))
+		go triggerVerifyAction()
+		_, err = deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		ExpectNotFound(ctx, env.Client, node)
+	})
 	It("should expire one node at a time, starting with most expired", func() {
 		expireProv := test.Provisioner(test.ProvisionerOptions{
 			TTLSecondsUntilExpired: ptr.Int64(100),
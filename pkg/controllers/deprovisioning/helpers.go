@@ -20,6 +20,7 @@ import (
 	"math"
 	"strconv"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 
 	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
@@ -28,7 +29,9 @@ import (
 	pscheduling "github.com/aws/karpenter-core/pkg/controllers/provisioning/scheduling"
 	"github.com/aws/karpenter-core/pkg/controllers/state"
 	"github.com/aws/karpenter-core/pkg/scheduling"
+	nodeutils "github.com/aws/karpenter-core/pkg/utils/node"
 	"github.com/aws/karpenter-core/pkg/utils/pod"
+	"github.com/aws/karpenter-core/pkg/utils/resources"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -135,15 +138,25 @@ func GetPodEvictionCost(ctx context.Context, p *v1.Pod) float64 {
 	return clamp(-10.0, cost, 10.0)
 }
 
-func filterByPrice(options []*cloudprovider.InstanceType, reqs scheduling.Requirements, price float64) []*cloudprovider.InstanceType {
+// filterByPrice returns the instance types whose worst case launch price saves at least the given fraction of price. It
+// returns an error if the remaining instance types don't satisfy the minimum values of the replacement's requirements,
+// since launching the replacement would leave it less flexible than the provisioner allows.
+func filterByPrice(options []*cloudprovider.InstanceType, reqs scheduling.Requirements, minValues map[string]int, price float64, savings float64) ([]*cloudprovider.InstanceType, error) {
 	var result []*cloudprovider.InstanceType
+	maxPrice := price * (1 - savings)
 	for _, it := range options {
 		launchPrice := worstLaunchPrice(it.Offerings.Available(), reqs)
-		if launchPrice < price {
+		if launchPrice < maxPrice {
 			result = append(result, it)
 		}
 	}
-	return result
+	if len(result) == 0 {
+		return nil, nil
+	}
+	if err := pscheduling.ValidateMinValues(result, minValues); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 func disruptionCost(ctx context.Context, pods []*v1.Pod) float64 {
@@ -180,6 +193,10 @@ func candidateNodes(ctx context.Context, cluster *state.Cluster, kubeClient clie
 		if provisioner == nil || instanceTypeMap == nil {
 			return true
 		}
+		// skip the nodes of paused provisioners
+		if provisioner.Spec.Paused {
+			return true
+		}
 
 		instanceType, ok := instanceTypeMap[n.Labels()[v1.LabelInstanceTypeStable]]
 		// skip any nodes that we can't determine the instance of
@@ -261,6 +278,137 @@ func buildProvisionerMap(ctx context.Context, kubeClient client.Client, cloudPro
 	return provisioners, instanceTypesByProvisioner, nil
 }
 
+// buildDisruptionBudgets builds a provName -> number of nodes map that holds how many more nodes of each provisioner
+// can be disrupted without exceeding the provisioner's disruption budgets
+func buildDisruptionBudgets(ctx context.Context, cluster *state.Cluster, kubeClient client.Client) (map[string]int, error) {
+	var provList v1alpha5.ProvisionerList
+	if err := kubeClient.List(ctx, &provList); err != nil {
+		return nil, fmt.Errorf("listing provisioners, %w", err)
+	}
+	numNodes := map[string]int{}
+	disrupting := map[string]int{}
+	cluster.ForEachNode(func(n *state.Node) bool {
+		provName, ok := n.Labels()[v1alpha5.ProvisionerNameLabelKey]
+		if !ok {
+			return true
+		}
+		numNodes[provName]++
+		if n.MarkedForDeletion() {
+			disrupting[provName]++
+		}
+		return true
+	})
+	budgets := map[string]int{}
+	for i := range provList.Items {
+		p := &provList.Items[i]
+		allowed, err := p.Spec.Disruption.AllowedDisruptions(numNodes[p.Name])
+		if err != nil {
+			return nil, fmt.Errorf("computing disruption budget for %s, %w", p.Name, err)
+		}
+		budgets[p.Name] = lo.Max([]int{allowed - disrupting[p.Name], 0})
+		if p.Spec.Disruption != nil && len(p.Spec.Disruption.Budgets) > 0 {
+			deprovisioningAllowedDisruptionsGauge.With(prometheus.Labels{"provisioner": p.Name}).Set(float64(budgets[p.Name]))
+		} else {
+			deprovisioningAllowedDisruptionsGauge.Delete(prometheus.Labels{"provisioner": p.Name})
+		}
+	}
+	return budgets, nil
+}
+
+// inMaintenanceWindow returns true if the provisioner currently allows its nodes to be voluntarily disrupted. Maintenance
+// windows that can't be evaluated keep the nodes from being disrupted, and are reported once per provisioner by its
+// MaintenanceWindowsValid status condition rather than for each of its nodes.
+func inMaintenanceWindow(clk clock.Clock, provisioner *v1alpha5.Provisioner) bool {
+	if provisioner == nil {
+		return false
+	}
+	ok, err := provisioner.Spec.Disruption.InMaintenanceWindow(clk.Now())
+	return err == nil && ok
+}
+
+// filterByDisruptionBudgets returns, in order, the candidates that can be disrupted together without exceeding the
+// remaining disruption budgets of their provisioners
+func filterByDisruptionBudgets(candidates []CandidateNode, budgets map[string]int) []CandidateNode {
+	remaining := lo.Assign(budgets)
+	return lo.Filter(candidates, func(cn CandidateNode, _ int) bool {
+		if remaining[cn.provisioner.Name] <= 0 {
+			return false
+		}
+		remaining[cn.provisioner.Name]--
+		return true
+	})
+}
+
+// filterByMinimumCapacity returns, in order, the candidates that can be removed together while keeping the nodes of
+// their provisioners at or above the minimum capacity. It's only meant for commands that delete nodes without
+// replacing them, see keepsMinimumCapacity for the others.
+func filterByMinimumCapacity(cluster *state.Cluster, candidates []CandidateNode) []CandidateNode {
+	allocatable, nodes := provisionedCapacity(cluster)
+	return lo.Filter(candidates, func(cn CandidateNode, _ int) bool {
+		remaining := resources.Subtract(allocatable[cn.provisioner.Name], cn.Node.Status.Allocatable)
+		if !cn.provisioner.Spec.MinimumCapacity.SatisfiedBy(remaining, nodes[cn.provisioner.Name]-1) {
+			return false
+		}
+		allocatable[cn.provisioner.Name] = remaining
+		nodes[cn.provisioner.Name]--
+		return true
+	})
+}
+
+// keepsMinimumCapacity returns true if the provisioners of the candidates stay at or above their minimum capacity once
+// the candidates are replaced by the replacements. Replacements count with the allocatable resources that all of their
+// instance type options have, as any of them may be launched.
+func keepsMinimumCapacity(cluster *state.Cluster, candidates []CandidateNode, replacements []*pscheduling.Machine) bool {
+	allocatable, nodes := provisionedCapacity(cluster)
+	for _, cn := range candidates {
+		allocatable[cn.provisioner.Name] = resources.Subtract(allocatable[cn.provisioner.Name], cn.Node.Status.Allocatable)
+		nodes[cn.provisioner.Name]--
+	}
+	for _, machine := range replacements {
+		allocatable[machine.ProvisionerName] = resources.Merge(allocatable[machine.ProvisionerName], smallestAllocatable(machine.InstanceTypeOptions))
+		nodes[machine.ProvisionerName]++
+	}
+	return lo.EveryBy(candidates, func(cn CandidateNode) bool {
+		return cn.provisioner.Spec.MinimumCapacity.SatisfiedBy(allocatable[cn.provisioner.Name], nodes[cn.provisioner.Name])
+	})
+}
+
+// provisionedCapacity returns the allocatable resources and the number of nodes of each provisioner, not counting the
+// nodes that are marked for deletion
+func provisionedCapacity(cluster *state.Cluster) (map[string]v1.ResourceList, map[string]int64) {
+	allocatable := map[string]v1.ResourceList{}
+	nodes := map[string]int64{}
+	cluster.ForEachNode(func(n *state.Node) bool {
+		provName, ok := n.Labels()[v1alpha5.ProvisionerNameLabelKey]
+		if !ok || n.MarkedForDeletion() {
+			return true
+		}
+		allocatable[provName] = resources.Merge(allocatable[provName], n.Allocatable())
+		nodes[provName]++
+		return true
+	})
+	return allocatable, nodes
+}
+
+// smallestAllocatable returns the allocatable resources that all of the instance types have
+func smallestAllocatable(instanceTypes []*cloudprovider.InstanceType) v1.ResourceList {
+	if len(instanceTypes) == 0 {
+		return nil
+	}
+	smallest := instanceTypes[0].Allocatable().DeepCopy()
+	for _, it := range instanceTypes[1:] {
+		allocatable := it.Allocatable()
+		for resourceName, quantity := range smallest {
+			if other, ok := allocatable[resourceName]; !ok {
+				delete(smallest, resourceName)
+			} else if other.Cmp(quantity) < 0 {
+				smallest[resourceName] = other
+			}
+		}
+	}
+	return smallest
+}
+
 // calculateLifetimeRemaining calculates the fraction of node lifetime remaining in the range [0.0, 1.0].  If the TTLSecondsUntilExpired
 // is non-zero, we use it to scale down the disruption costs of nodes that are going to expire.  Just after creation, the
 // disruption cost is highest and it approaches zero as the node ages towards its expiration time.
@@ -268,7 +416,7 @@ func calculateLifetimeRemaining(node CandidateNode, clock clock.Clock) float64 {
 	remaining := 1.0
 	if node.provisioner.Spec.TTLSecondsUntilExpired != nil {
 		ageInSeconds := clock.Since(node.CreationTimestamp.Time).Seconds()
-		totalLifetimeSeconds := float64(*node.provisioner.Spec.TTLSecondsUntilExpired)
+		totalLifetimeSeconds := nodeutils.GetExpirationTime(node.Node, node.provisioner).Sub(node.CreationTimestamp.Time).Seconds()
 		lifetimeRemainingSeconds := totalLifetimeSeconds - ageInSeconds
 		remaining = clamp(0.0, lifetimeRemainingSeconds/totalLifetimeSeconds, 1.0)
 	}
@@ -276,13 +424,22 @@ func calculateLifetimeRemaining(node CandidateNode, clock clock.Clock) float64 {
 }
 
 // worstLaunchPrice gets the worst-case launch price from the offerings that are offered
-// on an instance type. If the instance type has a spot offering available, then it uses the spot offering
+// on an instance type. If the instance type has a reserved offering available, the node is launched into the
+// reservation for free. If it has a spot offering available, then it uses the spot offering
 // to get the launch price; else, it uses the on-demand launch price
 func worstLaunchPrice(ofs []cloudprovider.Offering, reqs scheduling.Requirements) float64 {
+	// Reservations are preferred over everything else, as they are already paid for
+	if reqs.Get(v1alpha5.LabelCapacityType).Has(v1alpha5.CapacityTypeReserved) {
+		if lo.ContainsBy(ofs, func(of cloudprovider.Offering) bool {
+			return of.CapacityType == v1alpha5.CapacityTypeReserved && of.Compatible(reqs)
+		}) {
+			return 0
+		}
+	}
 	// We prefer to launch spot offerings, so we will get the worst price based on the node requirements
 	if reqs.Get(v1alpha5.LabelCapacityType).Has(v1alpha5.CapacityTypeSpot) {
 		spotOfferings := lo.Filter(ofs, func(of cloudprovider.Offering, _ int) bool {
-			return of.CapacityType == v1alpha5.CapacityTypeSpot && reqs.Get(v1.LabelTopologyZone).Has(of.Zone)
+			return of.CapacityType == v1alpha5.CapacityTypeSpot && of.Compatible(reqs)
 		})
 		if len(spotOfferings) > 0 {
 			return lo.MaxBy(spotOfferings, func(of1, of2 cloudprovider.Offering) bool {
@@ -292,7 +449,7 @@ func worstLaunchPrice(ofs []cloudprovider.Offering, reqs scheduling.Requirements
 	}
 	if reqs.Get(v1alpha5.LabelCapacityType).Has(v1alpha5.CapacityTypeOnDemand) {
 		onDemandOfferings := lo.Filter(ofs, func(of cloudprovider.Offering, _ int) bool {
-			return of.CapacityType == v1alpha5.CapacityTypeOnDemand && reqs.Get(v1.LabelTopologyZone).Has(of.Zone)
+			return of.CapacityType == v1alpha5.CapacityTypeOnDemand && of.Compatible(reqs)
 		})
 		if len(onDemandOfferings) > 0 {
 			return lo.MaxBy(onDemandOfferings, func(of1, of2 cloudprovider.Offering) bool {
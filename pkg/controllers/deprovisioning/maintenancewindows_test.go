@@ -0,0 +1,143 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deprovisioning_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/test"
+	. "github.com/aws/karpenter-core/pkg/test/expectations"
+)
+
+var _ = Describe("Maintenance Windows", func() {
+	var disruption *v1alpha5.Disruption
+	var windowStart time.Time
+
+	BeforeEach(func() {
+		// nightly window from 22:00 until 02:00 UTC
+		disruption = &v1alpha5.Disruption{MaintenanceWindows: []v1alpha5.MaintenanceWindow{
+			{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+		}}
+		windowStart = time.Date(2023, time.January, 4, 22, 0, 0, 0, time.UTC)
+	})
+	makeNode := func(prov *v1alpha5.Provisioner, annotations map[string]string) *v1.Node {
+		return test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1alpha5.ProvisionerNameLabelKey: prov.Name,
+					v1.LabelInstanceTypeStable:       mostExpensiveInstance.Name,
+					v1alpha5.LabelCapacityType:       mostExpensiveOffering.CapacityType,
+					v1.LabelTopologyZone:             mostExpensiveOffering.Zone,
+				},
+				Annotations: annotations,
+			},
+			Allocatable: map[v1.ResourceName]resource.Quantity{
+				v1.ResourceCPU:  resource.MustParse("32"),
+				v1.ResourcePods: resource.MustParse("100"),
+			}})
+	}
+
+	It("should not expire nodes outside of a maintenance window", func() {
+		fakeClock.SetTime(windowStart.Add(-2 * time.Hour))
+		prov := test.Provisioner(test.ProvisionerOptions{
+			TTLSecondsUntilExpired: ptr.Int64(30),
+			Disruption:             disruption,
+		})
+		node := makeNode(prov, nil)
+		ExpectApplied(ctx, env.Client, node, prov)
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+		fakeClock.Step(10 * time.Minute)
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		ExpectNodeExists(ctx, env.Client, node.Name)
+	})
+	It("should expire nodes once a maintenance window opens", func() {
+		fakeClock.SetTime(windowStart.Add(-2 * time.Hour))
+		prov := test.Provisioner(test.ProvisionerOptions{
+			TTLSecondsUntilExpired: ptr.Int64(30),
+			Disruption:             disruption,
+		})
+		node := makeNode(prov, nil)
+		ExpectApplied(ctx, env.Client, node, prov)
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+		fakeClock.SetTime(windowStart.Add(time.Hour))
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		ExpectNotFound(ctx, env.Client, node)
+	})
+	It("should not replace drifted nodes outside of a maintenance window", func() {
+		fakeClock.SetTime(windowStart.Add(5 * time.Hour))
+		prov := test.Provisioner(test.ProvisionerOptions{Disruption: disruption})
+		node := makeNode(prov, map[string]string{
+			v1alpha5.VoluntaryDisruptionAnnotationKey: v1alpha5.VoluntaryDisruptionDriftedAnnotationValue,
+		})
+		ExpectApplied(ctx, env.Client, node, prov)
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		ExpectNodeExists(ctx, env.Client, node.Name)
+	})
+	It("should not consolidate nodes outside of a maintenance window", func() {
+		fakeClock.SetTime(windowStart.Add(-6 * time.Hour))
+		prov := test.Provisioner(test.ProvisionerOptions{
+			Consolidation: &v1alpha5.Consolidation{Enabled: ptr.Bool(true)},
+			Disruption:    disruption,
+		})
+		node := makeNode(prov, nil)
+		ExpectApplied(ctx, env.Client, node, prov)
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+		fakeClock.Step(10 * time.Minute)
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		ExpectNodeExists(ctx, env.Client, node.Name)
+	})
+	It("should consolidate nodes inside of a maintenance window", func() {
+		fakeClock.SetTime(windowStart.Add(30 * time.Minute))
+		prov := test.Provisioner(test.ProvisionerOptions{
+			Consolidation: &v1alpha5.Consolidation{Enabled: ptr.Bool(true)},
+			Disruption:    disruption,
+		})
+		node := makeNode(prov, nil)
+		ExpectApplied(ctx, env.Client, node, prov)
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+		fakeClock.Step(10 * time.Minute)
+		go triggerVerifyAction()
+		_, err := deprovisioningController.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		ExpectNotFound(ctx, env.Client, node)
+	})
+})
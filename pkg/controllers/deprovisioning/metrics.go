@@ -25,6 +25,7 @@ func init() {
 	crmetrics.Registry.MustRegister(deprovisioningDurationHistogram)
 	crmetrics.Registry.MustRegister(deprovisioningReplacementNodeInitializedHistogram)
 	crmetrics.Registry.MustRegister(deprovisioningActionsPerformedCounter)
+	crmetrics.Registry.MustRegister(deprovisioningAllowedDisruptionsGauge)
 }
 
 const deprovisioningSubsystem = "deprovisioning"
@@ -58,3 +59,13 @@ var deprovisioningActionsPerformedCounter = prometheus.NewCounterVec(
 	},
 	[]string{"action"},
 )
+
+var deprovisioningAllowedDisruptionsGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: deprovisioningSubsystem,
+		Name:      "allowed_disruptions",
+		Help:      "Number of nodes that can currently be disrupted without exceeding the provisioner's disruption budgets. Labeled by provisioner.",
+	},
+	[]string{"provisioner"},
+)
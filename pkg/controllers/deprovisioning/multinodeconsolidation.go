@@ -38,7 +38,7 @@ func NewMultiNodeConsolidation(clk clock.Clock, cluster *state.Cluster, kubeClie
 	return &MultiNodeConsolidation{makeConsolidation(clk, cluster, kubeClient, provisioner, cp, reporter)}
 }
 
-func (m *MultiNodeConsolidation) ComputeCommand(ctx context.Context, candidates ...CandidateNode) (Command, error) {
+func (m *MultiNodeConsolidation) ComputeCommand(ctx context.Context, budgets map[string]int, candidates ...CandidateNode) (Command, error) {
 	if m.cluster.Consolidated() {
 		return Command{action: actionDoNothing}, nil
 	}
@@ -46,6 +46,9 @@ func (m *MultiNodeConsolidation) ComputeCommand(ctx context.Context, candidates
 	if err != nil {
 		return Command{}, fmt.Errorf("sorting candidates, %w", err)
 	}
+	// we consider a prefix of the candidates, so only keep as many candidates as the disruption budgets allow. The
+	// minimum capacity of their provisioners is checked for each prefix, as it depends on the replacements.
+	candidates = filterByDisruptionBudgets(candidates, budgets)
 
 	// For now, we will consider up to every node in the cluster, might be configurable in the future.
 	maxParallel := len(candidates)
@@ -96,12 +99,16 @@ func (m *MultiNodeConsolidation) firstNNodeConsolidationOption(ctx context.Conte
 		// ensure that the action is sensical for replacements, see explanation on filterOutSameType for why this is
 		// required
 		if action.action == actionReplace {
-			action.replacementNodes[0].InstanceTypeOptions = filterOutSameType(action.replacementNodes[0], nodesToConsolidate)
-			if len(action.replacementNodes[0].InstanceTypeOptions) == 0 {
+			action.replacementNodes[0].InstanceTypeOptions, err = filterOutSameType(action.replacementNodes[0], nodesToConsolidate)
+			if err != nil || len(action.replacementNodes[0].InstanceTypeOptions) == 0 {
 				action.action = actionDoNothing
 			}
 		}
 
+		if (action.action == actionReplace || action.action == actionDelete) && !keepsMinimumCapacity(m.cluster, nodesToConsolidate, action.replacementNodes) {
+			action.action = actionDoNothing
+		}
+
 		if action.action == actionReplace || action.action == actionDelete {
 			// we can consolidate nodes [0,mid]
 			lastSavedCommand = action
@@ -129,14 +136,14 @@ func (m *MultiNodeConsolidation) firstNNodeConsolidationOption(ctx context.Conte
 // This code sees that t3a.small is the cheapest type in both lists and filters it and anything more expensive out
 // leaving the valid consolidation:
 // nodes=[t3a.2xlarge, t3a.2xlarge, t3a.small] -> 1 of t3a.nano
-func filterOutSameType(newNode *scheduling.Machine, consolidate []CandidateNode) []*cloudprovider.InstanceType {
+func filterOutSameType(newNode *scheduling.Machine, consolidate []CandidateNode) ([]*cloudprovider.InstanceType, error) {
 	existingInstanceTypes := sets.NewString()
 	nodePricesByInstanceType := map[string]float64{}
 
 	// get the price of the cheapest node that we currently are considering deleting indexed by instance type
 	for _, n := range consolidate {
 		existingInstanceTypes.Insert(n.instanceType.Name)
-		of, ok := n.instanceType.Offerings.Get(n.capacityType, n.zone)
+		of, ok := n.offering()
 		if !ok {
 			continue
 		}
@@ -144,8 +151,8 @@ func filterOutSameType(newNode *scheduling.Machine, consolidate []CandidateNode)
 		if !ok {
 			existingPrice = math.MaxFloat64
 		}
-		if of.Price < existingPrice {
-			nodePricesByInstanceType[n.instanceType.Name] = of.Price
+		if of.EffectivePrice() < existingPrice {
+			nodePricesByInstanceType[n.instanceType.Name] = of.EffectivePrice()
 		}
 	}
 
@@ -161,5 +168,5 @@ func filterOutSameType(newNode *scheduling.Machine, consolidate []CandidateNode)
 		}
 	}
 
-	return filterByPrice(newNode.InstanceTypeOptions, newNode.Requirements, maxPrice)
+	return filterByPrice(newNode.InstanceTypeOptions, newNode.Requirements, newNode.MinValues, maxPrice, 0)
 }
@@ -40,7 +40,7 @@ func NewSingleNodeConsolidation(clk clock.Clock, cluster *state.Cluster, kubeCli
 // ComputeCommand generates a deprovisioning command given deprovisionable nodes
 //
 //nolint:gocyclo
-func (c *SingleNodeConsolidation) ComputeCommand(ctx context.Context, candidates ...CandidateNode) (Command, error) {
+func (c *SingleNodeConsolidation) ComputeCommand(ctx context.Context, budgets map[string]int, candidates ...CandidateNode) (Command, error) {
 	if c.cluster.Consolidated() {
 		return Command{action: actionDoNothing}, nil
 	}
@@ -52,6 +52,10 @@ func (c *SingleNodeConsolidation) ComputeCommand(ctx context.Context, candidates
 	v := NewValidation(consolidationTTL, c.clock, c.cluster, c.kubeClient, c.provisioner, c.cloudProvider)
 	var failedValidation bool
 	for _, node := range candidates {
+		if budgets[node.provisioner.Name] == 0 {
+			c.reporter.RecordUnconsolidatableReason(ctx, node.Node, fmt.Sprintf("disruption budget of provisioner %s is exhausted", node.provisioner.Name))
+			continue
+		}
 		// compute a possible consolidation option
 		cmd, err := c.computeConsolidation(ctx, node)
 		if err != nil {
@@ -61,6 +65,10 @@ func (c *SingleNodeConsolidation) ComputeCommand(ctx context.Context, candidates
 		if cmd.action == actionDoNothing || cmd.action == actionRetry {
 			continue
 		}
+		if !keepsMinimumCapacity(c.cluster, []CandidateNode{node}, cmd.replacementNodes) {
+			c.reporter.RecordUnconsolidatableReason(ctx, node.Node, fmt.Sprintf("node is part of the minimum capacity of provisioner %s", node.provisioner.Name))
+			continue
+		}
 
 		isValid, err := v.IsValid(ctx, cmd)
 		if err != nil {
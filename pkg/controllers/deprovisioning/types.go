@@ -29,7 +29,7 @@ import (
 
 type Deprovisioner interface {
 	ShouldDeprovision(context.Context, *state.Node, *v1alpha5.Provisioner, []*v1.Pod) bool
-	ComputeCommand(context.Context, ...CandidateNode) (Command, error)
+	ComputeCommand(context.Context, map[string]int, ...CandidateNode) (Command, error)
 	String() string
 }
 
@@ -0,0 +1,136 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/operator/controller"
+)
+
+// gracePeriod is how old an instance or a node has to be before it is garbage collected. Instances are launched before
+// their node is created, and may not be listed by the cloud provider immediately after they are launched.
+const gracePeriod = 5 * time.Minute
+
+const pollingPeriod = 2 * time.Minute
+
+// Controller deletes the cloud provider instances that aren't owned by a Machine or a Node, e.g. because the
+// controller restarted after launching the instance but before creating its node, and deletes the Nodes whose
+// instance no longer exists.
+type Controller struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+
+	// missing are the provider ids of the nodes whose instance wasn't listed by the cloud provider on the last poll.
+	// Nodes are only deleted once their instance is missing on two consecutive polls, so that a single incomplete
+	// listing doesn't delete nodes that are still running.
+	missing sets.String
+}
+
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		missing:       sets.NewString(),
+	}
+}
+
+func (c *Controller) Name() string {
+	return "garbagecollection"
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) controller.Builder {
+	return controller.NewSingletonManagedBy(m)
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	retrieved, err := c.cloudProvider.List(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing cloudprovider machines, %w", err)
+	}
+	machineList := &v1alpha5.MachineList{}
+	if err := c.kubeClient.List(ctx, machineList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing machines, %w", err)
+	}
+	nodeList := &v1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodeList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodes, %w", err)
+	}
+	owned := sets.NewString(lo.Map(machineList.Items, func(m v1alpha5.Machine, _ int) string { return m.Status.ProviderID })...)
+	owned.Insert(lo.Map(nodeList.Items, func(n v1.Node, _ int) string { return n.Spec.ProviderID })...)
+	existing := sets.NewString(lo.Map(retrieved, func(m *v1alpha5.Machine, _ int) string { return m.Status.ProviderID })...)
+
+	orphanedMachines := lo.Filter(retrieved, func(m *v1alpha5.Machine, _ int) bool {
+		return m.Status.ProviderID != "" && !owned.Has(m.Status.ProviderID) && c.clock.Since(m.CreationTimestamp.Time) > gracePeriod
+	})
+	provisionedNodes := lo.Filter(nodeList.Items, func(n v1.Node, _ int) bool {
+		_, ok := n.Labels[v1alpha5.ProvisionerNameLabelKey]
+		return ok && n.Spec.ProviderID != ""
+	})
+	orphanedNodes := c.orphanedNodes(ctx, retrieved, provisionedNodes, existing)
+
+	errs := make([]error, len(orphanedMachines)+len(orphanedNodes))
+	workqueue.ParallelizeUntil(ctx, 20, len(orphanedMachines), func(i int) {
+		ctx := logging.WithLogger(ctx, logging.FromContext(ctx).With("provider-id", orphanedMachines[i].Status.ProviderID))
+		if err := c.cloudProvider.Delete(ctx, orphanedMachines[i]); cloudprovider.IgnoreMachineNotFoundError(err) != nil {
+			errs[i] = fmt.Errorf("deleting cloudprovider machine, %w", err)
+			return
+		}
+		logging.FromContext(ctx).Infof("garbage collected cloudprovider machine without a machine or node")
+	})
+	for i := range orphanedNodes {
+		ctx := logging.WithLogger(ctx, logging.FromContext(ctx).With("node", orphanedNodes[i].Name))
+		if err := c.kubeClient.Delete(ctx, &orphanedNodes[i]); client.IgnoreNotFound(err) != nil {
+			errs[len(orphanedMachines)+i] = fmt.Errorf("deleting node, %w", err)
+			continue
+		}
+		logging.FromContext(ctx).Infof("garbage collected node without a cloudprovider machine")
+	}
+	return reconcile.Result{RequeueAfter: pollingPeriod}, multierr.Combine(errs...)
+}
+
+// orphanedNodes returns the nodes whose instance was missing from both this and the previous listing of the cloud
+// provider. A listing without any instance while there are nodes is assumed to be incomplete, e.g. due to an outage
+// or missing permissions of the cloud provider, rather than every instance having been terminated.
+func (c *Controller) orphanedNodes(ctx context.Context, retrieved []*v1alpha5.Machine, nodes []v1.Node, existing sets.String) []v1.Node {
+	if len(retrieved) == 0 && len(nodes) > 0 {
+		logging.FromContext(ctx).Infof("skipping node garbage collection, cloudprovider listed no machines for %d node(s)", len(nodes))
+		c.missing = sets.NewString()
+		return nil
+	}
+	missing := lo.Filter(nodes, func(n v1.Node, _ int) bool {
+		return !existing.Has(n.Spec.ProviderID) && n.DeletionTimestamp.IsZero() && c.clock.Since(n.CreationTimestamp.Time) > gracePeriod
+	})
+	orphaned := lo.Filter(missing, func(n v1.Node, _ int) bool { return c.missing.Has(n.Spec.ProviderID) })
+	c.missing = sets.NewString(lo.Map(missing, func(n v1.Node, _ int) string { return n.Spec.ProviderID })...)
+	return orphaned
+}
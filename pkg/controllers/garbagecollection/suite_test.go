@@ -0,0 +1,144 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollection_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	. "knative.dev/pkg/logging/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/aws/karpenter-core/pkg/apis"
+	"github.com/aws/karpenter-core/pkg/apis/settings"
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+	"github.com/aws/karpenter-core/pkg/controllers/garbagecollection"
+	"github.com/aws/karpenter-core/pkg/operator/scheme"
+	"github.com/aws/karpenter-core/pkg/test"
+	. "github.com/aws/karpenter-core/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+var garbageCollectionController *garbagecollection.Controller
+var provisioner *v1alpha5.Provisioner
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Controllers/GarbageCollection")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(scheme.Scheme, test.WithCRDs(apis.CRDs...))
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = settings.ToContext(ctx, test.Settings())
+	cloudProvider = fake.NewCloudProvider()
+	fakeClock = clock.NewFakeClock(time.Now())
+	garbageCollectionController = garbagecollection.NewController(fakeClock, env.Client, cloudProvider)
+	provisioner = test.Provisioner()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("GarbageCollection", func() {
+	var created *v1alpha5.Machine
+
+	BeforeEach(func() {
+		var err error
+		created, err = cloudProvider.Create(ctx, test.Machine(v1alpha5.Machine{ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name},
+		}}))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should delete cloudprovider machines without a machine or node after the grace period", func() {
+		fakeClock.Step(10 * time.Minute)
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		Expect(cloudProvider.CreatedMachines).To(BeEmpty())
+	})
+	It("should not delete cloudprovider machines within the grace period", func() {
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		Expect(cloudProvider.CreatedMachines).To(HaveKey(created.Name))
+	})
+	It("should not delete cloudprovider machines that have a node", func() {
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}},
+			ProviderID: created.Status.ProviderID,
+		})
+		ExpectApplied(ctx, env.Client, node)
+		fakeClock.Step(10 * time.Minute)
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		Expect(cloudProvider.CreatedMachines).To(HaveKey(created.Name))
+		ExpectNodeExists(ctx, env.Client, node.Name)
+	})
+	It("should not delete cloudprovider machines that have a machine", func() {
+		machine := test.Machine(v1alpha5.Machine{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}},
+			Status:     v1alpha5.MachineStatus{ProviderID: created.Status.ProviderID},
+		})
+		ExpectApplied(ctx, env.Client, machine)
+		fakeClock.Step(10 * time.Minute)
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		Expect(cloudProvider.CreatedMachines).To(HaveKey(created.Name))
+	})
+	It("should delete nodes whose cloudprovider machine doesn't exist on two consecutive polls after the grace period", func() {
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}},
+			ProviderID: test.ProviderID(test.RandomName()),
+		})
+		ExpectApplied(ctx, env.Client, node)
+		fakeClock.Step(10 * time.Minute)
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		ExpectNodeExists(ctx, env.Client, node.Name)
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		ExpectNotFound(ctx, env.Client, node)
+	})
+	It("should not delete nodes when the cloudprovider lists no machines", func() {
+		Expect(cloudProvider.Delete(ctx, created)).To(Succeed())
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}},
+			ProviderID: created.Status.ProviderID,
+		})
+		ExpectApplied(ctx, env.Client, node)
+		fakeClock.Step(10 * time.Minute)
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		ExpectNodeExists(ctx, env.Client, node.Name)
+	})
+	It("should not delete nodes that weren't launched by a provisioner", func() {
+		node := test.Node(test.NodeOptions{ProviderID: test.ProviderID(test.RandomName())})
+		ExpectApplied(ctx, env.Client, node)
+		fakeClock.Step(10 * time.Minute)
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		ExpectNodeExists(ctx, env.Client, node.Name)
+	})
+})
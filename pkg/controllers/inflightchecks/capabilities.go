@@ -0,0 +1,85 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inflightchecks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+
+	"github.com/aws/karpenter-core/pkg/apis/settings"
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/controllers/deprovisioning"
+)
+
+// Capabilities detects nodes that are configured to use features that the cloud provider doesn't support, so that
+// the reduced behavior doesn't go unnoticed. As the capabilities are the same for all the nodes of a provisioner, each
+// issue is only reported on one of its nodes per scan period.
+type Capabilities struct {
+	clock    clock.Clock
+	provider cloudprovider.CloudProvider
+
+	mu       sync.Mutex
+	reported map[string]time.Time
+}
+
+func NewCapabilities(clk clock.Clock, provider cloudprovider.CloudProvider) Check {
+	return &Capabilities{
+		clock:    clk,
+		provider: provider,
+		reported: map[string]time.Time{},
+	}
+}
+
+func (c *Capabilities) Check(ctx context.Context, node *v1.Node, provisioner *v1alpha5.Provisioner, _ *deprovisioning.PDBLimits) ([]Issue, error) {
+	// ignore nodes that are deleting
+	if !node.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+	capabilities := c.provider.Capabilities()
+	var messages []string
+	if settings.FromContext(ctx).DriftEnabled && !capabilities.Drift {
+		messages = append(messages, fmt.Sprintf("Cloud provider %s doesn't detect drift, nodes of provisioner %s are only drifted by changes to the provisioner", c.provider.Name(), provisioner.Name))
+	}
+	if provisioner.Spec.Consolidation != nil && lo.FromPtr(provisioner.Spec.Consolidation.Enabled) && !capabilities.Pricing {
+		messages = append(messages, fmt.Sprintf("Cloud provider %s doesn't report prices, nodes of provisioner %s can be deleted but not replaced by consolidation", c.provider.Name(), provisioner.Name))
+	}
+	return lo.FilterMap(messages, func(message string, _ int) (Issue, bool) {
+		return Issue{node: node, message: message}, c.shouldReport(message)
+	}), nil
+}
+
+// shouldReport returns true if the message wasn't reported within the last scan period
+func (c *Capabilities) shouldReport(message string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock.Now()
+	for m, reported := range c.reported {
+		if now.Sub(reported) >= scanPeriod {
+			delete(c.reported, m)
+		}
+	}
+	if _, ok := c.reported[message]; ok {
+		return false
+	}
+	c.reported[message] = now
+	return true
+}
@@ -72,6 +72,7 @@ func NewController(clk clock.Clock, kubeClient client.Client, recorder events.Re
 			NewFailedInit(clk, provider),
 			NewTermination(kubeClient),
 			NewNodeShape(provider),
+			NewCapabilities(clk, provider),
 		}},
 	)
 }
@@ -29,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	clock "k8s.io/utils/clock/testing"
 	. "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/aws/karpenter-core/pkg/apis"
@@ -60,7 +61,7 @@ var _ = BeforeSuite(func() {
 	fakeClock = clock.NewFakeClock(time.Now())
 	env = test.NewEnvironment(scheme.Scheme, test.WithCRDs(apis.CRDs...))
 	ctx = settings.ToContext(ctx, test.Settings())
-	cp = &fake.CloudProvider{}
+	cp = fake.NewCloudProvider()
 	recorder = NewFakeEventRecorder()
 	inflightController = inflightchecks.NewController(fakeClock, env.Client, recorder, cp)
 })
@@ -77,6 +78,7 @@ var _ = Describe("Controller", func() {
 			Spec:       v1alpha5.ProvisionerSpec{},
 		}
 		recorder.Reset()
+		cp.Reset()
 	})
 
 	AfterEach(func() {
@@ -182,6 +184,53 @@ var _ = Describe("Controller", func() {
 			ExpectDetectedEvent("Expected 128Gi of resource memory, but found 64Gi (50.0% of expected)")
 		})
 	})
+
+	Context("Capabilities", func() {
+		var n *v1.Node
+		BeforeEach(func() {
+			n = test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1alpha5.ProvisionerNameLabelKey: provisioner.Name,
+						v1.LabelInstanceTypeStable:       "default-instance-type",
+					},
+				},
+			})
+		})
+		AfterEach(func() {
+			ctx = settings.ToContext(ctx, test.Settings())
+		})
+		It("should detect nodes that can't be replaced by consolidation when the cloud provider doesn't report prices", func() {
+			cp.SupportedCapabilities.Pricing = false
+			provisioner.Spec.Consolidation = &v1alpha5.Consolidation{Enabled: ptr.Bool(true)}
+			ExpectApplied(ctx, env.Client, provisioner, n)
+			ExpectReconcileSucceeded(ctx, inflightController, client.ObjectKeyFromObject(n))
+			ExpectDetectedEvent(fmt.Sprintf("Cloud provider fake doesn't report prices, nodes of provisioner %s can be deleted but not replaced by consolidation", provisioner.Name))
+		})
+		It("should detect nodes that are only drifted by their provisioner when the cloud provider doesn't detect drift", func() {
+			ctx = settings.ToContext(ctx, test.Settings(settings.Settings{DriftEnabled: true}))
+			cp.SupportedCapabilities.Drift = false
+			ExpectApplied(ctx, env.Client, provisioner, n)
+			ExpectReconcileSucceeded(ctx, inflightController, client.ObjectKeyFromObject(n))
+			ExpectDetectedEvent(fmt.Sprintf("Cloud provider fake doesn't detect drift, nodes of provisioner %s are only drifted by changes to the provisioner", provisioner.Name))
+		})
+		It("should only report the capabilities once per provisioner", func() {
+			cp.SupportedCapabilities.Pricing = false
+			provisioner.Spec.Consolidation = &v1alpha5.Consolidation{Enabled: ptr.Bool(true)}
+			other := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: n.Labels}})
+			ExpectApplied(ctx, env.Client, provisioner, n, other)
+			ExpectReconcileSucceeded(ctx, inflightController, client.ObjectKeyFromObject(n))
+			ExpectReconcileSucceeded(ctx, inflightController, client.ObjectKeyFromObject(other))
+			Expect(recorder.Calls("FailedInflightCheck")).To(Equal(1))
+		})
+		It("should not report capabilities that the cloud provider supports", func() {
+			ctx = settings.ToContext(ctx, test.Settings(settings.Settings{DriftEnabled: true}))
+			provisioner.Spec.Consolidation = &v1alpha5.Consolidation{Enabled: ptr.Bool(true)}
+			ExpectApplied(ctx, env.Client, provisioner, n)
+			ExpectReconcileSucceeded(ctx, inflightController, client.ObjectKeyFromObject(n))
+			Expect(recorder.Calls("FailedInflightCheck")).To(BeZero())
+		})
+	})
 })
 
 var _ events.Recorder = (*FakeEventRecorder)(nil)
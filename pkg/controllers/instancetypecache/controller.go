@@ -0,0 +1,62 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetypecache
+
+import (
+	"context"
+
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	corecontroller "github.com/aws/karpenter-core/pkg/operator/controller"
+)
+
+var _ corecontroller.TypedController[*v1alpha5.Provisioner] = (*Controller)(nil)
+
+// Controller invalidates the cached instance types of provisioners when they change
+type Controller struct {
+	instanceTypeCache *cloudprovider.InstanceTypeCache
+}
+
+func NewController(kubeClient client.Client, instanceTypeCache *cloudprovider.InstanceTypeCache) corecontroller.Controller {
+	return corecontroller.Typed[*v1alpha5.Provisioner](kubeClient, &Controller{
+		instanceTypeCache: instanceTypeCache,
+	})
+}
+
+func (c *Controller) Name() string {
+	return "instancetypecache"
+}
+
+func (c *Controller) Reconcile(_ context.Context, provisioner *v1alpha5.Provisioner) (reconcile.Result, error) {
+	// The cache is keyed by the provisioner hash, so this only drops entries that would otherwise wait for the TTL
+	c.instanceTypeCache.Invalidate(provisioner.Name)
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.Adapt(controllerruntime.
+		NewControllerManagedBy(m).
+		For(&v1alpha5.Provisioner{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		WithEventFilter(predicate.GenerationChangedPredicate{}),
+	)
+}
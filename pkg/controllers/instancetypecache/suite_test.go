@@ -0,0 +1,131 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetypecache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	clock "k8s.io/utils/clock/testing"
+	. "knative.dev/pkg/logging/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/aws/karpenter-core/pkg/apis"
+	"github.com/aws/karpenter-core/pkg/apis/settings"
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+	"github.com/aws/karpenter-core/pkg/controllers/instancetypecache"
+	"github.com/aws/karpenter-core/pkg/operator/controller"
+	"github.com/aws/karpenter-core/pkg/operator/scheme"
+	"github.com/aws/karpenter-core/pkg/test"
+	. "github.com/aws/karpenter-core/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var fakeCloudProvider *fake.CloudProvider
+var instanceTypeCache *cloudprovider.InstanceTypeCache
+var cachedCloudProvider cloudprovider.CloudProvider
+var instanceTypeCacheController controller.Controller
+var provisioner *v1alpha5.Provisioner
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Controllers/InstanceTypeCache")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(scheme.Scheme, test.WithCRDs(apis.CRDs...))
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = settings.ToContext(ctx, test.Settings())
+	fakeClock = clock.NewFakeClock(time.Now())
+	fakeCloudProvider = fake.NewCloudProvider()
+	fakeCloudProvider.InstanceTypes = []*cloudprovider.InstanceType{fake.NewInstanceType(fake.InstanceTypeOptions{Name: "cached-instance-type"})}
+	instanceTypeCache = cloudprovider.NewInstanceTypeCache(fakeClock)
+	cachedCloudProvider = cloudprovider.DecorateWithInstanceTypeCache(fakeCloudProvider, instanceTypeCache)
+	instanceTypeCacheController = instancetypecache.NewController(env.Client, instanceTypeCache)
+	provisioner = test.Provisioner()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("InstanceTypeCache", func() {
+	BeforeEach(func() {
+		ExpectInstanceTypeNames(provisioner, "cached-instance-type")
+		// the cloud provider's instance types change after they were cached
+		fakeCloudProvider.InstanceTypes = []*cloudprovider.InstanceType{fake.NewInstanceType(fake.InstanceTypeOptions{Name: "new-instance-type"})}
+	})
+	It("should return cached instance types", func() {
+		ExpectInstanceTypeNames(provisioner, "cached-instance-type")
+	})
+	It("should return new instance types once the TTL expires", func() {
+		fakeClock.Step(cloudprovider.InstanceTypesTTL)
+		ExpectInstanceTypeNames(provisioner, "new-instance-type")
+	})
+	It("should return new instance types when the provisioner spec changes", func() {
+		provisioner.Spec.Labels = map[string]string{"test-key": "test-value"}
+		ExpectInstanceTypeNames(provisioner, "new-instance-type")
+	})
+	It("should return new instance types when the provider ref changes", func() {
+		provisioner.Spec.ProviderRef = &v1alpha5.ProviderRef{APIVersion: "test.karpenter.sh/v1", Kind: "Provider", Name: "default"}
+		ExpectInstanceTypeNames(provisioner, "new-instance-type")
+		fakeCloudProvider.InstanceTypes = []*cloudprovider.InstanceType{fake.NewInstanceType(fake.InstanceTypeOptions{Name: "newer-instance-type"})}
+		ExpectInstanceTypeNames(provisioner, "new-instance-type")
+
+		provisioner.Spec.ProviderRef.Name = "other"
+		ExpectInstanceTypeNames(provisioner, "newer-instance-type")
+	})
+	It("should cache instance types per provisioner", func() {
+		ExpectInstanceTypeNames(test.Provisioner(), "new-instance-type")
+		ExpectInstanceTypeNames(provisioner, "cached-instance-type")
+	})
+	It("should invalidate the instance types of a provisioner when it's reconciled", func() {
+		ExpectApplied(ctx, env.Client, provisioner)
+		ExpectReconcileSucceeded(ctx, instanceTypeCacheController, client.ObjectKeyFromObject(provisioner))
+		ExpectInstanceTypeNames(provisioner, "new-instance-type")
+	})
+	It("should invalidate all instance types and call the change hooks when the cloud provider signals a change", func() {
+		watchCtx, cancel := context.WithCancel(ctx)
+		DeferCleanup(cancel)
+		changed := make(chan struct{}, 1)
+		instanceTypeCache.OnChange(func() { changed <- struct{}{} })
+		instanceTypeCache.Watch(watchCtx, fakeCloudProvider.InstanceTypesChanged())
+
+		fakeCloudProvider.UpdatePrices(func(_ *cloudprovider.InstanceType, o cloudprovider.Offering) float64 { return o.Price / 2 })
+		Eventually(changed).Should(Receive())
+		ExpectInstanceTypeNames(provisioner, "new-instance-type")
+	})
+})
+
+func ExpectInstanceTypeNames(provisioner *v1alpha5.Provisioner, names ...string) {
+	instanceTypes, err := cachedCloudProvider.GetInstanceTypes(ctx, provisioner)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	ExpectWithOffset(1, lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) string { return it.Name })).To(ConsistOf(names))
+}
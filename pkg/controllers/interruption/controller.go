@@ -0,0 +1,225 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/controllers/machine/terminator"
+	"github.com/aws/karpenter-core/pkg/controllers/provisioning"
+	"github.com/aws/karpenter-core/pkg/controllers/provisioning/scheduling"
+	"github.com/aws/karpenter-core/pkg/controllers/state"
+	"github.com/aws/karpenter-core/pkg/events"
+	"github.com/aws/karpenter-core/pkg/metrics"
+	"github.com/aws/karpenter-core/pkg/operator/controller"
+	podutils "github.com/aws/karpenter-core/pkg/utils/pod"
+)
+
+const pollingPeriod = 5 * time.Second
+
+// maintenanceLeadTime is how long before the deadline of a scheduled maintenance that the node is replaced. Nodes
+// that are going to be interrupted are replaced right away.
+const maintenanceLeadTime = 15 * time.Minute
+
+// Controller receives the notifications of a cloud provider that its instances are going to be disrupted. The node of
+// the instance is cordoned, and unless the notification is only a rebalance recommendation, a replacement is launched
+// for its pods and the node is deleted so that it is drained by the termination controller ahead of the deadline.
+type Controller struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	recorder      events.Recorder
+	cluster       *state.Cluster
+	provisioner   *provisioning.Provisioner
+	terminator    *terminator.Terminator
+	notifications <-chan cloudprovider.Notification
+
+	// pending are the notifications that haven't been acted on yet, keyed by provider id
+	pending map[string]cloudprovider.Notification
+	// unlaunched are the replacements that still have to be launched for the nodes that are being replaced, keyed by
+	// provider id. Replacements are only simulated once per node, so that a node that failed to be deleted or whose
+	// replacements partially failed to launch isn't replaced again from scratch on the next poll.
+	unlaunched map[string][]*scheduling.Machine
+}
+
+func NewController(clk clock.Clock, kubeClient client.Client, recorder events.Recorder, cluster *state.Cluster,
+	provisioner *provisioning.Provisioner, terminator *terminator.Terminator, notifications <-chan cloudprovider.Notification) *Controller {
+	return &Controller{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		recorder:      recorder,
+		cluster:       cluster,
+		provisioner:   provisioner,
+		terminator:    terminator,
+		notifications: notifications,
+		pending:       map[string]cloudprovider.Notification{},
+		unlaunched:    map[string][]*scheduling.Machine{},
+	}
+}
+
+func (c *Controller) Name() string {
+	return "interruption"
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) controller.Builder {
+	return controller.NewSingletonManagedBy(m)
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	c.receive()
+	if len(c.pending) == 0 {
+		return reconcile.Result{RequeueAfter: pollingPeriod}, nil
+	}
+	nodeList := &v1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodeList, client.HasLabels{v1alpha5.ProvisionerNameLabelKey}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodes, %w", err)
+	}
+	nodes := lo.SliceToMap(nodeList.Items, func(n v1.Node) (string, v1.Node) { return n.Spec.ProviderID, n })
+
+	var errs error
+	for providerID, notification := range c.pending {
+		node, ok := nodes[providerID]
+		// notifications for instances that aren't karpenter nodes, or whose nodes are already being deleted, are dropped
+		if !ok || !node.DeletionTimestamp.IsZero() {
+			c.forget(providerID)
+			continue
+		}
+		ctx := logging.WithLogger(ctx, logging.FromContext(ctx).With("node", node.Name, "notification", notification.Kind))
+		done, err := c.handle(ctx, notification, &node)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("handling %s, %w", notification, err))
+			continue
+		}
+		if done {
+			c.forget(providerID)
+		}
+	}
+	return reconcile.Result{RequeueAfter: pollingPeriod}, errs
+}
+
+// forget drops the notification of the instance along with its replacements that are left to launch
+func (c *Controller) forget(providerID string) {
+	delete(c.pending, providerID)
+	delete(c.unlaunched, providerID)
+}
+
+// receive moves the notifications that the cloud provider sent since the last reconcile into pending, without blocking
+func (c *Controller) receive() {
+	for {
+		select {
+		case notification, ok := <-c.notifications:
+			if !ok {
+				return
+			}
+			c.pending[notification.ProviderID] = notification
+		default:
+			return
+		}
+	}
+}
+
+// handle cordons the node of the notification, and replaces it once the deadline is close enough. It returns true when
+// nothing is left to do for the notification.
+func (c *Controller) handle(ctx context.Context, notification cloudprovider.Notification, node *v1.Node) (bool, error) {
+	message := notification.Message
+	if message == "" {
+		message = fmt.Sprintf("Received %s", notification)
+	}
+	c.recorder.Publish(events.NodeNotification(node, string(notification.Kind), message))
+	if err := c.terminator.Cordon(ctx, node); err != nil {
+		return false, fmt.Errorf("cordoning node, %w", err)
+	}
+	switch notification.Kind {
+	case cloudprovider.RebalanceRecommendation:
+		// the instance isn't going away yet, so we only keep new pods off of it
+		return true, nil
+	case cloudprovider.ScheduledMaintenance:
+		if !notification.Deadline.IsZero() && notification.Deadline.Sub(c.clock.Now()) > maintenanceLeadTime {
+			return false, nil
+		}
+	}
+	if err := c.replace(ctx, node); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// replace launches new capacity for the pods of the node before deleting it, so that the pods have somewhere to go
+// when the node is drained. Once the replacements have launched, later calls only retry the launches that failed and
+// the delete.
+func (c *Controller) replace(ctx context.Context, node *v1.Node) error {
+	machines, simulated := c.unlaunched[node.Spec.ProviderID]
+	if !simulated {
+		var err error
+		if machines, err = c.simulate(ctx, node); err != nil {
+			return err
+		}
+	}
+	machineNames, err := c.provisioner.LaunchReplacements(ctx, machines, []string{node.Name}, provisioning.RecordPodNomination)
+	metrics.NodesCreatedCounter.WithLabelValues(metrics.InterruptionReason).Add(float64(lo.CountBy(machineNames, func(name string) bool { return name != "" })))
+	c.unlaunched[node.Spec.ProviderID] = lo.Filter(machines, func(_ *scheduling.Machine, i int) bool {
+		return i >= len(machineNames) || machineNames[i] == ""
+	})
+	if err != nil {
+		return fmt.Errorf("launching replacement nodes, %w", err)
+	}
+	// the node stays marked for deletion if the delete fails, as its replacements have already launched
+	if err := c.kubeClient.Delete(ctx, node); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("deleting node, %w", err)
+	}
+	logging.FromContext(ctx).Infof("deleted node ahead of disruption")
+	metrics.NodesTerminatedCounter.WithLabelValues(metrics.InterruptionReason).Inc()
+	return nil
+}
+
+// simulate returns the machines that need to be launched for the pods of the node that don't fit on the other nodes
+func (c *Controller) simulate(ctx context.Context, node *v1.Node) ([]*scheduling.Machine, error) {
+	stateNode, ok := lo.Find(c.cluster.Nodes(), func(n *state.Node) bool { return n.Name() == node.Name })
+	if !ok {
+		return nil, fmt.Errorf("node isn't tracked in cluster state yet")
+	}
+	pods, err := stateNode.Pods(ctx, c.kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("determining node pods, %w", err)
+	}
+	pods = lo.Filter(pods, func(p *v1.Pod, _ int) bool {
+		return !podutils.IsTerminal(p) && !podutils.IsTerminating(p) && !podutils.IsOwnedByDaemonSet(p) && !podutils.IsOwnedByNode(p)
+	})
+	if len(pods) == 0 {
+		return nil, nil
+	}
+	stateNodes := lo.Filter(c.cluster.Nodes().Active(), func(n *state.Node, _ int) bool { return n.Name() != node.Name })
+	scheduler, err := c.provisioner.NewScheduler(ctx, pods, stateNodes, scheduling.SchedulerOptions{SimulationMode: true})
+	if err != nil {
+		return nil, fmt.Errorf("creating scheduler, %w", err)
+	}
+	machines, _, err := scheduler.Solve(ctx, pods)
+	if err != nil {
+		return nil, fmt.Errorf("simulating scheduling, %w", err)
+	}
+	return machines, nil
+}
@@ -0,0 +1,170 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	. "knative.dev/pkg/logging/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/aws/karpenter-core/pkg/apis"
+	"github.com/aws/karpenter-core/pkg/apis/settings"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+	"github.com/aws/karpenter-core/pkg/controllers/interruption"
+	"github.com/aws/karpenter-core/pkg/controllers/machine/terminator"
+	"github.com/aws/karpenter-core/pkg/controllers/provisioning"
+	"github.com/aws/karpenter-core/pkg/controllers/state"
+	"github.com/aws/karpenter-core/pkg/controllers/state/informer"
+	"github.com/aws/karpenter-core/pkg/events"
+	"github.com/aws/karpenter-core/pkg/operator/controller"
+	"github.com/aws/karpenter-core/pkg/operator/scheme"
+	"github.com/aws/karpenter-core/pkg/test"
+	. "github.com/aws/karpenter-core/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+var cluster *state.Cluster
+var nodeStateController controller.Controller
+var provisioner *provisioning.Provisioner
+var interruptionController *interruption.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Controllers/Interruption")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(scheme.Scheme, test.WithCRDs(apis.CRDs...))
+	ctx = settings.ToContext(ctx, test.Settings())
+	cloudProvider = fake.NewCloudProvider()
+	fakeClock = clock.NewFakeClock(time.Now())
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	nodeStateController = informer.NewNodeController(env.Client, cluster)
+	recorder := events.NewRecorder(&record.FakeRecorder{})
+	provisioner = provisioning.NewProvisioner(ctx, env.Client, env.KubernetesInterface.CoreV1(), recorder, cloudProvider, cluster)
+	evictionQueue := terminator.NewEvictionQueue(ctx, env.KubernetesInterface.CoreV1(), recorder)
+	interruptionController = interruption.NewController(fakeClock, env.Client, recorder, cluster, provisioner,
+		terminator.NewTerminator(fakeClock, env.Client, cloudProvider, evictionQueue), cloudProvider.Notifications())
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	cloudProvider.Reset()
+	cluster.Reset()
+	fakeClock.SetTime(time.Now())
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Interruption", func() {
+	var node *v1.Node
+	BeforeEach(func() {
+		ExpectApplied(ctx, env.Client, test.Provisioner())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, provisioner, pod)
+		node = ExpectScheduled(ctx, env.Client, pod)
+		ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+	})
+	It("should cordon, replace and delete a node that is going to be interrupted", func() {
+		cloudProvider.Notify(cloudprovider.Notification{Kind: cloudprovider.Interruption, ProviderID: node.Spec.ProviderID})
+		ExpectReconcileSucceeded(ctx, interruptionController, client.ObjectKey{})
+
+		node = ExpectNodeExists(ctx, env.Client, node.Name)
+		Expect(node.Spec.Unschedulable).To(BeTrue())
+		Expect(node.DeletionTimestamp.IsZero()).To(BeFalse())
+		Expect(cloudProvider.CreateCalls).To(HaveLen(2))
+	})
+	It("should not delete or mark a node for deletion when its replacement can't be launched", func() {
+		cloudProvider.NextCreateErr = fmt.Errorf("failed to launch")
+		cloudProvider.Notify(cloudprovider.Notification{Kind: cloudprovider.Interruption, ProviderID: node.Spec.ProviderID})
+		ExpectReconcileFailed(ctx, interruptionController, client.ObjectKey{})
+
+		node = ExpectNodeExists(ctx, env.Client, node.Name)
+		Expect(node.DeletionTimestamp.IsZero()).To(BeTrue())
+		stateNode, ok := lo.Find(cluster.Nodes(), func(n *state.Node) bool { return n.Name() == node.Name })
+		Expect(ok).To(BeTrue())
+		Expect(stateNode.MarkedForDeletion()).To(BeFalse())
+	})
+	It("should only retry the replacements that failed to launch", func() {
+		cloudProvider.NextCreateErr = fmt.Errorf("failed to launch")
+		cloudProvider.Notify(cloudprovider.Notification{Kind: cloudprovider.Interruption, ProviderID: node.Spec.ProviderID})
+		ExpectReconcileFailed(ctx, interruptionController, client.ObjectKey{})
+		Expect(cloudProvider.CreateCalls).To(HaveLen(2))
+
+		ExpectReconcileSucceeded(ctx, interruptionController, client.ObjectKey{})
+		node = ExpectNodeExists(ctx, env.Client, node.Name)
+		Expect(node.DeletionTimestamp.IsZero()).To(BeFalse())
+		Expect(cloudProvider.CreateCalls).To(HaveLen(3))
+
+		ExpectReconcileSucceeded(ctx, interruptionController, client.ObjectKey{})
+		Expect(cloudProvider.CreateCalls).To(HaveLen(3))
+	})
+	It("should only cordon a node that is recommended to be rebalanced", func() {
+		cloudProvider.Notify(cloudprovider.Notification{Kind: cloudprovider.RebalanceRecommendation, ProviderID: node.Spec.ProviderID})
+		ExpectReconcileSucceeded(ctx, interruptionController, client.ObjectKey{})
+
+		node = ExpectNodeExists(ctx, env.Client, node.Name)
+		Expect(node.Spec.Unschedulable).To(BeTrue())
+		Expect(node.DeletionTimestamp.IsZero()).To(BeTrue())
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+	})
+	It("should wait to replace a node that is going to be maintained until the deadline is close", func() {
+		cloudProvider.Notify(cloudprovider.Notification{
+			Kind:       cloudprovider.ScheduledMaintenance,
+			ProviderID: node.Spec.ProviderID,
+			Deadline:   fakeClock.Now().Add(time.Hour),
+		})
+		ExpectReconcileSucceeded(ctx, interruptionController, client.ObjectKey{})
+		node = ExpectNodeExists(ctx, env.Client, node.Name)
+		Expect(node.Spec.Unschedulable).To(BeTrue())
+		Expect(node.DeletionTimestamp.IsZero()).To(BeTrue())
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+
+		fakeClock.Step(50 * time.Minute)
+		ExpectReconcileSucceeded(ctx, interruptionController, client.ObjectKey{})
+		node = ExpectNodeExists(ctx, env.Client, node.Name)
+		Expect(node.DeletionTimestamp.IsZero()).To(BeFalse())
+		Expect(cloudProvider.CreateCalls).To(HaveLen(2))
+	})
+	It("should ignore notifications for instances that aren't nodes", func() {
+		cloudProvider.Notify(cloudprovider.Notification{Kind: cloudprovider.Interruption, ProviderID: "fake:///unknown"})
+		ExpectReconcileSucceeded(ctx, interruptionController, client.ObjectKey{})
+
+		node = ExpectNodeExists(ctx, env.Client, node.Name)
+		Expect(node.Spec.Unschedulable).To(BeFalse())
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+	})
+})
@@ -36,7 +36,7 @@ func (l *Launch) Reconcile(ctx context.Context, machine *v1alpha5.Machine) (reco
 	if machine.Status.ProviderID != "" {
 		return reconcile.Result{}, nil
 	}
-	retrieved, err := l.cloudProvider.Get(ctx, machine.Name, machine.Labels[v1alpha5.ProvisionerNameLabelKey])
+	retrieved, err := l.get(ctx, machine)
 	if err != nil {
 		if cloudprovider.IsMachineNotFoundError(err) {
 			logging.FromContext(ctx).Debugf("creating machine")
@@ -53,6 +53,15 @@ func (l *Launch) Reconcile(ctx context.Context, machine *v1alpha5.Machine) (reco
 	return reconcile.Result{}, nil
 }
 
+// get retrieves a previous launch of the machine. Cloud providers that can't get machines by name are treated as if
+// the machine wasn't launched yet.
+func (l *Launch) get(ctx context.Context, machine *v1alpha5.Machine) (*v1alpha5.Machine, error) {
+	if !l.cloudProvider.Capabilities().GetByName {
+		return nil, cloudprovider.NewMachineNotFoundError(fmt.Errorf("cloud provider %s can't get machines by name", l.cloudProvider.Name()))
+	}
+	return l.cloudProvider.Get(ctx, machine.Name, machine.Labels[v1alpha5.ProvisionerNameLabelKey])
+}
+
 func populateMachineDetails(machine, retrieved *v1alpha5.Machine) {
 	machine.Labels = lo.Assign(machine.Labels, retrieved.Labels, map[string]string{
 		v1alpha5.MachineNameLabelKey: machine.Name,
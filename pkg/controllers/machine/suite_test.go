@@ -150,6 +150,20 @@ var _ = Describe("Controller", func() {
 			Expect(machine.Labels).To(HaveKeyWithValue(v1.LabelTopologyRegion, "test-zone"))
 			Expect(machine.Labels).To(HaveKeyWithValue(v1alpha5.LabelCapacityType, v1alpha5.CapacityTypeSpot))
 		})
+		It("should launch an instance without getting it when the cloud provider can't get machines by name", func() {
+			cloudProvider.SupportedCapabilities.GetByName = false
+			machine := test.Machine()
+			cloudProvider.CreatedMachines[machine.Name] = &v1alpha5.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: machine.Name},
+				Status:     v1alpha5.MachineStatus{ProviderID: test.RandomProviderID()},
+			}
+			ExpectApplied(ctx, env.Client, machine)
+			ExpectReconcileSucceeded(ctx, machineController, client.ObjectKeyFromObject(machine))
+
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+			machine = ExpectExists(ctx, env.Client, machine)
+			Expect(machine.Status.ProviderID).To(Equal(cloudProvider.CreatedMachines[machine.Name].Status.ProviderID))
+		})
 		It("should add the MachineCreated status condition after creating the Machine", func() {
 			machine := test.Machine()
 			ExpectApplied(ctx, env.Client, machine)
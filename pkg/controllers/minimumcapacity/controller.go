@@ -0,0 +1,140 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minimumcapacity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/controllers/provisioning"
+	"github.com/aws/karpenter-core/pkg/controllers/provisioning/scheduling"
+	"github.com/aws/karpenter-core/pkg/controllers/state"
+	corecontroller "github.com/aws/karpenter-core/pkg/operator/controller"
+	"github.com/aws/karpenter-core/pkg/utils/resources"
+)
+
+var _ corecontroller.TypedController[*v1alpha5.Provisioner] = (*Controller)(nil)
+
+// Controller launches nodes for provisioners whose nodes fall short of their minimum capacity
+type Controller struct {
+	kubeClient    client.Client
+	provisioner   *provisioning.Provisioner
+	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
+}
+
+// NewController is a constructor
+func NewController(kubeClient client.Client, provisioner *provisioning.Provisioner, cloudProvider cloudprovider.CloudProvider,
+	cluster *state.Cluster) corecontroller.Controller {
+	return corecontroller.Typed[*v1alpha5.Provisioner](kubeClient, &Controller{
+		kubeClient:    kubeClient,
+		provisioner:   provisioner,
+		cloudProvider: cloudProvider,
+		cluster:       cluster,
+	})
+}
+
+func (c *Controller) Name() string {
+	return "minimumcapacity"
+}
+
+// Reconcile launches the nodes that are missing from the minimum capacity of the provisioner
+func (c *Controller) Reconcile(ctx context.Context, provisioner *v1alpha5.Provisioner) (reconcile.Result, error) {
+	if provisioner.Spec.MinimumCapacity == nil || provisioner.Spec.Paused {
+		return reconcile.Result{}, nil
+	}
+	// We can't tell how much capacity the provisioner has until the cluster state is synced
+	if !c.cluster.Synced(ctx) {
+		return reconcile.Result{RequeueAfter: time.Second}, nil
+	}
+	count := c.machinesToLaunch(provisioner)
+	if count == 0 {
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, provisioner)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting instance types, %w", err)
+	}
+	machines := make([]*scheduling.Machine, count)
+	for i := range machines {
+		if machines[i], err = scheduling.NewEmptyMachine(scheduling.NewMachineTemplate(provisioner), instanceTypes); err != nil {
+			return reconcile.Result{}, fmt.Errorf("creating machine, %w", err)
+		}
+	}
+	logging.FromContext(ctx).Infof("launching %d machine(s) to meet the minimum capacity", count)
+	if _, err := c.provisioner.LaunchMachines(ctx, machines); err != nil {
+		return reconcile.Result{}, fmt.Errorf("launching machines, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+}
+
+// machinesToLaunch returns the number of machines that should be launched to meet the minimum capacity. The node
+// deficit is launched all at once. Nodes are launched one at a time to meet the minimum resources, since we don't know
+// the allocatable resources of a node until it has launched.
+func (c *Controller) machinesToLaunch(provisioner *v1alpha5.Provisioner) int {
+	var allocatable v1.ResourceList
+	var nodes int64
+	c.cluster.ForEachNode(func(n *state.Node) bool {
+		// Nodes that are being deleted don't count towards the minimum capacity
+		if n.MarkedForDeletion() || n.Labels()[v1alpha5.ProvisionerNameLabelKey] != provisioner.Name {
+			return true
+		}
+		allocatable = resources.Merge(allocatable, n.Allocatable())
+		nodes++
+		return true
+	})
+	_, reserved := c.cluster.Reserved(provisioner.Name)
+	nodes += reserved
+
+	minimum := provisioner.Spec.MinimumCapacity
+	if minimum.Nodes != nil && nodes < *minimum.Nodes {
+		return int(*minimum.Nodes - nodes)
+	}
+	// Wait for outstanding launches to register before judging whether more resources are needed
+	if reserved == 0 && !minimum.SatisfiedBy(allocatable, nodes) {
+		return 1
+	}
+	return 0
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.Adapt(controllerruntime.
+		NewControllerManagedBy(m).
+		For(&v1alpha5.Provisioner{}).
+		Watches(
+			&source.Kind{Type: &v1.Node{}},
+			handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
+				if name, ok := o.GetLabels()[v1alpha5.ProvisionerNameLabelKey]; ok {
+					return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name}}}
+				}
+				return nil
+			}),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}))
+}
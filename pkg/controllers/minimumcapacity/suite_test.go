@@ -0,0 +1,150 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minimumcapacity_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	. "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/aws/karpenter-core/pkg/apis"
+	"github.com/aws/karpenter-core/pkg/apis/settings"
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
+	"github.com/aws/karpenter-core/pkg/controllers/minimumcapacity"
+	"github.com/aws/karpenter-core/pkg/controllers/provisioning"
+	"github.com/aws/karpenter-core/pkg/controllers/state"
+	"github.com/aws/karpenter-core/pkg/controllers/state/informer"
+	"github.com/aws/karpenter-core/pkg/events"
+	"github.com/aws/karpenter-core/pkg/operator/controller"
+	"github.com/aws/karpenter-core/pkg/operator/scheme"
+	"github.com/aws/karpenter-core/pkg/test"
+	. "github.com/aws/karpenter-core/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cluster *state.Cluster
+var cloudProvider *fake.CloudProvider
+var nodeController controller.Controller
+var minimumCapacityController controller.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Controllers/MinimumCapacity")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(scheme.Scheme, test.WithCRDs(apis.CRDs...))
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = settings.ToContext(ctx, test.Settings())
+	cloudProvider = fake.NewCloudProvider()
+	fakeClock = clock.NewFakeClock(time.Now())
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	nodeController = informer.NewNodeController(env.Client, cluster)
+	provisioner := provisioning.NewProvisioner(ctx, env.Client, env.KubernetesInterface.CoreV1(), events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster)
+	minimumCapacityController = minimumcapacity.NewController(env.Client, provisioner, cloudProvider, cluster)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("MinimumCapacity", func() {
+	It("should not launch nodes for a provisioner without a minimum capacity", func() {
+		provisioner := test.Provisioner()
+		ExpectApplied(ctx, env.Client, provisioner)
+		ExpectReconcileSucceeded(ctx, minimumCapacityController, client.ObjectKeyFromObject(provisioner))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+	})
+	It("should not launch nodes for a paused provisioner", func() {
+		provisioner := test.Provisioner(test.ProvisionerOptions{MinimumCapacity: &v1alpha5.MinimumCapacity{Nodes: ptr.Int64(1)}, Paused: true})
+		ExpectApplied(ctx, env.Client, provisioner)
+		ExpectReconcileSucceeded(ctx, minimumCapacityController, client.ObjectKeyFromObject(provisioner))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+	})
+	It("should launch the nodes that are missing from the minimum node count", func() {
+		provisioner := test.Provisioner(test.ProvisionerOptions{MinimumCapacity: &v1alpha5.MinimumCapacity{Nodes: ptr.Int64(3)}})
+		node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}}})
+		ExpectApplied(ctx, env.Client, provisioner, node)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+		ExpectReconcileSucceeded(ctx, minimumCapacityController, client.ObjectKeyFromObject(provisioner))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(2))
+		Expect(cloudProvider.CreateCalls[0].Labels).To(HaveKeyWithValue(v1alpha5.ProvisionerNameLabelKey, provisioner.Name))
+
+		// the launched nodes are tracked by the cluster state, so nothing else is launched
+		ExpectReconcileSucceeded(ctx, minimumCapacityController, client.ObjectKeyFromObject(provisioner))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(2))
+	})
+	It("should not count nodes that are marked for deletion", func() {
+		provisioner := test.Provisioner(test.ProvisionerOptions{MinimumCapacity: &v1alpha5.MinimumCapacity{Nodes: ptr.Int64(1)}})
+		node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}}})
+		ExpectApplied(ctx, env.Client, provisioner, node)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		cluster.MarkForDeletion(node.Name)
+
+		ExpectReconcileSucceeded(ctx, minimumCapacityController, client.ObjectKeyFromObject(provisioner))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+	})
+	It("should launch a node when the nodes have less than the minimum resources", func() {
+		provisioner := test.Provisioner(test.ProvisionerOptions{MinimumCapacity: &v1alpha5.MinimumCapacity{
+			Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+		}})
+		node := test.Node(test.NodeOptions{
+			ObjectMeta:  metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}},
+			Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+		})
+		ExpectApplied(ctx, env.Client, provisioner, node)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+		ExpectReconcileSucceeded(ctx, minimumCapacityController, client.ObjectKeyFromObject(provisioner))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+	})
+	It("should not launch nodes when the minimum resources are met", func() {
+		provisioner := test.Provisioner(test.ProvisionerOptions{MinimumCapacity: &v1alpha5.MinimumCapacity{
+			Nodes:     ptr.Int64(1),
+			Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+		}})
+		node := test.Node(test.NodeOptions{
+			ObjectMeta:  metav1.ObjectMeta{Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name}},
+			Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+		})
+		ExpectApplied(ctx, env.Client, provisioner, node)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+		ExpectReconcileSucceeded(ctx, minimumCapacityController, client.ObjectKeyFromObject(provisioner))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+	})
+})
@@ -53,6 +53,7 @@ type Controller struct {
 	cluster        *state.Cluster
 	initialization *Initialization
 	emptiness      *Emptiness
+	expiration     *Expiration
 	finalizer      *Finalizer
 	drift          *Drift
 }
@@ -64,6 +65,7 @@ func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider clou
 		cluster:        cluster,
 		initialization: &Initialization{kubeClient: kubeClient, cloudProvider: cloudProvider},
 		emptiness:      &Emptiness{kubeClient: kubeClient, clock: clk, cluster: cluster},
+		expiration:     &Expiration{},
 		drift:          &Drift{kubeClient: kubeClient, cloudProvider: cloudProvider},
 	})
 }
@@ -94,6 +96,7 @@ func (c *Controller) Reconcile(ctx context.Context, node *v1.Node) (reconcile.Re
 	reconcilers := []nodeReconciler{
 		c.initialization,
 		c.emptiness,
+		c.expiration,
 		c.finalizer,
 	}
 	if settings.FromContext(ctx).DriftEnabled {
@@ -40,10 +40,9 @@ func (d *Drift) Reconcile(ctx context.Context, provisioner *v1alpha5.Provisioner
 		return reconcile.Result{}, nil
 	}
 
-	// TODO: Add Provisioner Drift
-	drifted, err := d.cloudProvider.IsMachineDrifted(ctx, machine.NewFromNode(node))
+	drifted, err := d.isDrifted(ctx, provisioner, node)
 	if err != nil {
-		return reconcile.Result{}, fmt.Errorf("getting drift for node, %w", err)
+		return reconcile.Result{}, err
 	}
 	if drifted {
 		node.Annotations = lo.Assign(node.Annotations, map[string]string{
@@ -53,3 +52,27 @@ func (d *Drift) Reconcile(ctx context.Context, provisioner *v1alpha5.Provisioner
 	// Requeue after 5 minutes for the cache TTL
 	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
 }
+
+// isDrifted returns true if the provisioner's launch-relevant fields have changed since the node was launched or if the
+// cloud provider considers the machine of the node to be drifted
+func (d *Drift) isDrifted(ctx context.Context, provisioner *v1alpha5.Provisioner, node *v1.Node) (bool, error) {
+	hash, ok := node.Annotations[v1alpha5.ProvisionerHashAnnotationKey]
+	if !ok {
+		// Nodes that were launched before we started stamping the provisioner hash adopt the current hash, since we
+		// can't know which provisioner spec they were launched with
+		node.Annotations = lo.Assign(node.Annotations, map[string]string{
+			v1alpha5.ProvisionerHashAnnotationKey: provisioner.Hash(),
+		})
+	} else if hash != provisioner.Hash() {
+		return true, nil
+	}
+	// Cloud providers that don't detect drift would always report that the machine isn't drifted
+	if !d.cloudProvider.Capabilities().Drift {
+		return false, nil
+	}
+	drifted, err := d.cloudProvider.IsMachineDrifted(ctx, machine.NewFromNode(node))
+	if err != nil {
+		return false, fmt.Errorf("getting drift for node, %w", err)
+	}
+	return drifted, nil
+}
@@ -0,0 +1,42 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	nodeutils "github.com/aws/karpenter-core/pkg/utils/node"
+)
+
+// Expiration is a subreconciler that annotates nodes with the time at which they expire
+type Expiration struct{}
+
+// Reconcile reconciles the node
+func (r *Expiration) Reconcile(_ context.Context, provisioner *v1alpha5.Provisioner, n *v1.Node) (reconcile.Result, error) {
+	if provisioner.Spec.TTLSecondsUntilExpired == nil {
+		delete(n.Annotations, v1alpha5.ExpirationTimeAnnotationKey)
+		return reconcile.Result{}, nil
+	}
+	n.Annotations = lo.Assign(n.Annotations, map[string]string{
+		v1alpha5.ExpirationTimeAnnotationKey: nodeutils.GetExpirationTime(n, provisioner).Format(time.RFC3339),
+	})
+	return reconcile.Result{}, nil
+}
@@ -133,6 +133,75 @@ var _ = Describe("Controller", func() {
 			node = ExpectNodeExists(ctx, env.Client, node.Name)
 			Expect(node.Annotations).To(HaveKeyWithValue(v1alpha5.VoluntaryDisruptionAnnotationKey, v1alpha5.VoluntaryDisruptionDriftedAnnotationValue))
 		})
+		It("should not detect drift in the cloud provider if it doesn't support drift detection", func() {
+			cp.Drifted = true
+			cp.SupportedCapabilities.Drift = false
+			DeferCleanup(func() {
+				cp.SupportedCapabilities.Drift = true
+			})
+			node := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1alpha5.ProvisionerNameLabelKey: provisioner.Name,
+						v1.LabelInstanceTypeStable:       test.RandomName(),
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, provisioner, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Annotations).ToNot(HaveKey(v1alpha5.VoluntaryDisruptionAnnotationKey))
+		})
+		It("should annotate the node when the provisioner hash has changed", func() {
+			node := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1alpha5.ProvisionerNameLabelKey: provisioner.Name,
+						v1.LabelInstanceTypeStable:       test.RandomName(),
+					},
+					Annotations: map[string]string{
+						v1alpha5.ProvisionerHashAnnotationKey: provisioner.Hash(),
+					},
+				},
+			})
+			provisioner.Spec.Labels = map[string]string{"test-key": "test-value"}
+			ExpectApplied(ctx, env.Client, provisioner, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Annotations).To(HaveKeyWithValue(v1alpha5.VoluntaryDisruptionAnnotationKey, v1alpha5.VoluntaryDisruptionDriftedAnnotationValue))
+		})
+		It("should not annotate the node when the provisioner hash matches", func() {
+			node := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1alpha5.ProvisionerNameLabelKey: provisioner.Name,
+						v1.LabelInstanceTypeStable:       test.RandomName(),
+					},
+					Annotations: map[string]string{
+						v1alpha5.ProvisionerHashAnnotationKey: provisioner.Hash(),
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, provisioner, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Annotations).ToNot(HaveKey(v1alpha5.VoluntaryDisruptionAnnotationKey))
+		})
+		It("should stamp the provisioner hash on nodes that don't have one", func() {
+			node := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1alpha5.ProvisionerNameLabelKey: provisioner.Name,
+						v1.LabelInstanceTypeStable:       test.RandomName(),
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, provisioner, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Annotations).To(HaveKeyWithValue(v1alpha5.ProvisionerHashAnnotationKey, provisioner.Hash()))
+			Expect(node.Annotations).ToNot(HaveKey(v1alpha5.VoluntaryDisruptionAnnotationKey))
+		})
 	})
 
 	Context("Initialization", func() {
@@ -384,6 +453,46 @@ var _ = Describe("Controller", func() {
 			Expect(node.Annotations).ToNot(HaveKey(v1alpha5.EmptinessTimestampAnnotationKey))
 		})
 	})
+	Context("Expiration", func() {
+		It("should annotate nodes with their expiration time", func() {
+			provisioner.Spec.TTLSecondsUntilExpired = ptr.Int64(300)
+			node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name},
+			}})
+			ExpectApplied(ctx, env.Client, provisioner, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Annotations).To(HaveKeyWithValue(v1alpha5.ExpirationTimeAnnotationKey, node.CreationTimestamp.Add(300*time.Second).Format(time.RFC3339)))
+		})
+		It("should spread the expiration time within the jitter window", func() {
+			provisioner.Spec.TTLSecondsUntilExpired = ptr.Int64(300)
+			provisioner.Spec.TTLSecondsUntilExpiredJitter = ptr.Int64(3600)
+			node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name},
+			}})
+			ExpectApplied(ctx, env.Client, provisioner, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Annotations).To(HaveKey(v1alpha5.ExpirationTimeAnnotationKey))
+			expirationTime, err := time.Parse(time.RFC3339, node.Annotations[v1alpha5.ExpirationTimeAnnotationKey])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(expirationTime).To(BeTemporally(">=", node.CreationTimestamp.Add(300*time.Second)))
+			Expect(expirationTime).To(BeTemporally("<", node.CreationTimestamp.Add(300*time.Second+time.Hour)))
+		})
+		It("should remove the expiration time when TTLSecondsUntilExpired is unset", func() {
+			node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name},
+				Annotations: map[string]string{v1alpha5.ExpirationTimeAnnotationKey: fakeClock.Now().Format(time.RFC3339)},
+			}})
+			ExpectApplied(ctx, env.Client, provisioner, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Annotations).ToNot(HaveKey(v1alpha5.ExpirationTimeAnnotationKey))
+		})
+	})
 	Context("Finalizer", func() {
 		It("should add the termination finalizer if missing", func() {
 			n := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{
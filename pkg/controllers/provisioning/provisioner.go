@@ -55,6 +55,7 @@ import (
 // actions and configuration during scheduling
 type LaunchOptions struct {
 	RecordPodNomination bool
+	ReplacedNodeNames   []string
 }
 
 // RecordPodNomination causes nominate pod events to be recorded against the node.
@@ -63,6 +64,15 @@ func RecordPodNomination(o LaunchOptions) LaunchOptions {
 	return o
 }
 
+// ReplacingNodes causes the nodes to be left out of the provisioner limits, as they are going to be deleted once their
+// replacements have launched.
+func ReplacingNodes(nodeNames ...string) functional.Option[LaunchOptions] {
+	return func(o LaunchOptions) LaunchOptions {
+		o.ReplacedNodeNames = append(o.ReplacedNodeNames, nodeNames...)
+		return o
+	}
+}
+
 // Provisioner waits for enqueued pods, batches them, creates capacity and binds the pods to the capacity.
 type Provisioner struct {
 	cloudProvider  cloudprovider.CloudProvider
@@ -126,18 +136,17 @@ func (p *Provisioner) Reconcile(ctx context.Context, _ reconcile.Request) (resul
 }
 
 // LaunchMachines launches nodes passed into the function in parallel. It returns a slice of the successfully created node
-// names as well as a multierr of any errors that occurred while launching nodes
+// names as well as a multierr of any errors that occurred while launching nodes. If the cloud provider supports batch
+// create, the machines are launched with a single call to the cloud provider.
 func (p *Provisioner) LaunchMachines(ctx context.Context, machines []*scheduler.Machine, opts ...functional.Option[LaunchOptions]) ([]string, error) {
+	if _, ok := p.cloudProvider.(cloudprovider.BatchCreator); ok && p.cloudProvider.Capabilities().BatchCreate && len(machines) > 1 {
+		return p.launchBatch(ctx, machines, opts...)
+	}
 	// Launch capacity and bind pods
 	errs := make([]error, len(machines))
 	machineNames := make([]string, len(machines))
 	workqueue.ParallelizeUntil(ctx, len(machines), len(machines), func(i int) {
-		// create a new context to avoid a data race on the ctx variable
-		ctx := logging.WithLogger(ctx, logging.FromContext(ctx).With("provisioner", machines[i].Labels[v1alpha5.ProvisionerNameLabelKey]))
-		// register the provisioner on the context so we can pull it off for tagging purposes
-		// TODO: rethink this, maybe just pass the provisioner down instead of hiding it in the context?
-		ctx = injection.WithNamespacedName(ctx, types.NamespacedName{Name: machines[i].Labels[v1alpha5.ProvisionerNameLabelKey]})
-		if machineName, err := p.Launch(ctx, machines[i], opts...); err != nil {
+		if machineName, err := p.Launch(machineContext(ctx, machines[i]), machines[i], opts...); err != nil {
 			errs[i] = fmt.Errorf("launching machine, %w", err)
 		} else {
 			machineNames[i] = machineName
@@ -149,6 +158,72 @@ func (p *Provisioner) LaunchMachines(ctx context.Context, machines []*scheduler.
 	return machineNames, nil
 }
 
+// LaunchReplacements launches the machines that replace the nodes, without counting the nodes against the provisioner
+// limits. The nodes are marked for deletion once the machines have been created, so that their capacity is no longer
+// considered by scheduling, and are left as they are if the launch fails. Just like LaunchMachines, the names of the
+// machines that were created are returned along with the error when only some of the machines fail to launch.
+func (p *Provisioner) LaunchReplacements(ctx context.Context, machines []*scheduler.Machine, nodeNames []string, opts ...functional.Option[LaunchOptions]) ([]string, error) {
+	machineNames, err := p.LaunchMachines(ctx, machines, append(opts, ReplacingNodes(nodeNames...))...)
+	if err != nil {
+		return machineNames, err
+	}
+	if len(machineNames) != len(machines) {
+		// shouldn't ever occur since a partially failed LaunchMachines should return an error
+		return nil, fmt.Errorf("expected %d machine names, got %d", len(machines), len(machineNames))
+	}
+	p.cluster.MarkForDeletion(nodeNames...)
+	return machineNames, nil
+}
+
+// launchBatch launches the machines with a single batch create call to the cloud provider. Limits are checked and
+// nodes are registered for each machine individually, just like Launch.
+func (p *Provisioner) launchBatch(ctx context.Context, machines []*scheduler.Machine, opts ...functional.Option[LaunchOptions]) ([]string, error) {
+	errs := make([]error, len(machines))
+	machineNames := make([]string, len(machines))
+	provisioners := make([]*v1alpha5.Provisioner, len(machines))
+	reservations := make([]*state.Reservation, len(machines))
+	workqueue.ParallelizeUntil(ctx, len(machines), len(machines), func(i int) {
+		if provisioners[i], reservations[i], errs[i] = p.reserve(machineContext(ctx, machines[i]), machines[i], opts...); errs[i] != nil {
+			errs[i] = fmt.Errorf("launching machine, %w", errs[i])
+		}
+	})
+	var batch []*v1alpha5.Machine
+	var indices []int
+	for i := range machines {
+		if errs[i] != nil {
+			continue
+		}
+		batch = append(batch, machines[i].ToMachine(provisioners[i]))
+		indices = append(indices, i)
+	}
+	if len(batch) > 0 {
+		for _, i := range indices {
+			logging.FromContext(machineContext(ctx, machines[i])).Infof("launching %s", machines[i])
+		}
+		results := cloudprovider.CreateBatch(logging.WithLogger(ctx, logging.FromContext(ctx).Named("cloudprovider")), p.cloudProvider, batch)
+		workqueue.ParallelizeUntil(ctx, len(indices), len(indices), func(j int) {
+			i := indices[j]
+			// guard against cloud providers that return fewer results than machines
+			result := cloudprovider.CreateResult{Err: fmt.Errorf("cloud provider returned no result")}
+			if j < len(results) {
+				result = results[j]
+			}
+			if machineNames[i], errs[i] = p.settle(machineContext(ctx, machines[i]), machines[i], provisioners[i], reservations[i], result.Machine, result.Err, opts...); errs[i] != nil {
+				errs[i] = fmt.Errorf("launching machine, %w", errs[i])
+			}
+		})
+	}
+	return machineNames, multierr.Combine(errs...)
+}
+
+// machineContext returns a context that is scoped to the provisioner of the machine
+func machineContext(ctx context.Context, machine *scheduler.Machine) context.Context {
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("provisioner", machine.Labels[v1alpha5.ProvisionerNameLabelKey]))
+	// register the provisioner on the context so we can pull it off for tagging purposes
+	// TODO: rethink this, maybe just pass the provisioner down instead of hiding it in the context?
+	return injection.WithNamespacedName(ctx, types.NamespacedName{Name: machine.Labels[v1alpha5.ProvisionerNameLabelKey]})
+}
+
 func (p *Provisioner) GetPendingPods(ctx context.Context) ([]*v1.Pod, error) {
 	var podList v1.PodList
 	if err := p.kubeClient.List(ctx, &podList, client.MatchingFields{"spec.nodeName": ""}); err != nil {
@@ -215,6 +290,10 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*v1.Pod, stateNod
 		if !provisioner.DeletionTimestamp.IsZero() {
 			continue
 		}
+		// Paused provisioners don't launch nodes
+		if provisioner.Spec.Paused {
+			continue
+		}
 		// Create node template
 		machines = append(machines, scheduler.NewMachineTemplate(provisioner))
 		// Get instance type options
@@ -228,7 +307,7 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*v1.Pod, stateNod
 		for _, instanceType := range instanceTypeOptions {
 			// We need to intersect the instance type requirements with the current provisioner requirements.  This
 			// ensures that something like zones from an instance type don't expand the universe of valid domains.
-			requirements := scheduling.NewNodeSelectorRequirements(provisioner.Spec.Requirements...)
+			requirements := scheduling.NewNodeSelectorRequirements(provisioner.Spec.NodeSelectorRequirements()...)
 			requirements.Add(instanceType.Requirements.Values()...)
 
 			for key, requirement := range requirements {
@@ -236,7 +315,7 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*v1.Pod, stateNod
 			}
 		}
 
-		for key, requirement := range scheduling.NewNodeSelectorRequirements(provisioner.Spec.Requirements...) {
+		for key, requirement := range scheduling.NewNodeSelectorRequirements(provisioner.Spec.NodeSelectorRequirements()...) {
 			if requirement.Operator() == v1.NodeSelectorOpIn {
 				domains[key] = domains[key].Union(sets.NewString(requirement.Values()...))
 			}
@@ -302,23 +381,47 @@ func (p *Provisioner) Schedule(ctx context.Context) ([]*scheduler.Machine, []*sc
 }
 
 func (p *Provisioner) Launch(ctx context.Context, machine *scheduler.Machine, opts ...functional.Option[LaunchOptions]) (string, error) {
-	// Check limits
-	latest := &v1alpha5.Provisioner{}
-	if err := p.kubeClient.Get(ctx, types.NamespacedName{Name: machine.ProvisionerName}, latest); err != nil {
-		return "", fmt.Errorf("getting current resource usage, %w", err)
-	}
-	if err := latest.Spec.Limits.ExceededBy(latest.Status.Resources); err != nil {
+	latest, reservation, err := p.reserve(ctx, machine, opts...)
+	if err != nil {
 		return "", err
 	}
-
 	logging.FromContext(ctx).Infof("launching %s", machine)
 	created, err := p.cloudProvider.Create(
 		logging.WithLogger(ctx, logging.FromContext(ctx).Named("cloudprovider")),
 		machine.ToMachine(latest),
 	)
+	return p.settle(ctx, machine, latest, reservation, created, err, opts...)
+}
+
+// reserve checks the limits of the machine's provisioner and reserves the capacity that the machine could launch with
+func (p *Provisioner) reserve(ctx context.Context, machine *scheduler.Machine, opts ...functional.Option[LaunchOptions]) (*v1alpha5.Provisioner, *state.Reservation, error) {
+	replacedNodeNames := functional.ResolveOptions(opts...).ReplacedNodeNames
+	// Check limits
+	latest := &v1alpha5.Provisioner{}
+	if err := p.kubeClient.Get(ctx, types.NamespacedName{Name: machine.ProvisionerName}, latest); err != nil {
+		return nil, nil, fmt.Errorf("getting current resource usage, %w", err)
+	}
+	if err := latest.Spec.Limits.ExceededBy(latest.Status.Resources); err != nil {
+		return nil, nil, err
+	}
+	// The provisioner status is updated asynchronously, so we reserve the maximum capacity that the machine could launch
+	// with to ensure that concurrent launches can't exceed the limits
+	reservation, err := p.cluster.Reserve(latest, machine, replacedNodeNames...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return latest, reservation, nil
+}
+
+// settle records the result of creating the machine with the cloud provider and registers its node
+func (p *Provisioner) settle(ctx context.Context, machine *scheduler.Machine, latest *v1alpha5.Provisioner, reservation *state.Reservation,
+	created *v1alpha5.Machine, err error, opts ...functional.Option[LaunchOptions]) (string, error) {
+	p.cluster.RecordLaunch(latest.Name, err)
 	if err != nil {
+		p.cluster.Release(reservation)
 		return "", fmt.Errorf("creating cloud provider instance, %w", err)
 	}
+	p.cluster.Settle(reservation, created.Status.ProviderID)
 	k8sNode := &v1.Node{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   created.Name,
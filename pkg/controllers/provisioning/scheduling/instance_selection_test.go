@@ -46,12 +46,12 @@ var _ = Describe("Instance Type Selection", func() {
 
 	BeforeEach(func() {
 		// open up the provisioner to any instance types
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelArchStable,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{v1alpha5.ArchitectureArm64, v1alpha5.ArchitectureAmd64},
-			},
+			}},
 		}
 		cloudProv.CreateCalls = nil
 		cloudProv.InstanceTypes = fake.InstanceTypesAssorted()
@@ -104,12 +104,12 @@ var _ = Describe("Instance Type Selection", func() {
 		ExpectInstancesWithLabel(supportedInstanceTypes(cloudProv.CreateCalls[0]), v1.LabelArchStable, v1alpha5.ArchitectureArm64)
 	})
 	It("should schedule on one of the cheapest instances (prov arch = amd64)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelArchStable,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{v1alpha5.ArchitectureAmd64},
-			},
+			}},
 		}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod()
@@ -119,12 +119,12 @@ var _ = Describe("Instance Type Selection", func() {
 		ExpectInstancesWithLabel(supportedInstanceTypes(cloudProv.CreateCalls[0]), v1.LabelArchStable, v1alpha5.ArchitectureAmd64)
 	})
 	It("should schedule on one of the cheapest instances (prov arch = arm64)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelArchStable,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{v1alpha5.ArchitectureArm64},
-			},
+			}},
 		}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod()
@@ -134,12 +134,12 @@ var _ = Describe("Instance Type Selection", func() {
 		ExpectInstancesWithLabel(supportedInstanceTypes(cloudProv.CreateCalls[0]), v1.LabelArchStable, v1alpha5.ArchitectureArm64)
 	})
 	It("should schedule on one of the cheapest instances (prov os = windows)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelOSStable,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{string(v1.Windows)},
-			},
+			}},
 		}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod()
@@ -162,12 +162,12 @@ var _ = Describe("Instance Type Selection", func() {
 		ExpectInstancesWithLabel(supportedInstanceTypes(cloudProv.CreateCalls[0]), v1.LabelOSStable, string(v1.Windows))
 	})
 	It("should schedule on one of the cheapest instances (prov os = windows)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelOSStable,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{string(v1.Windows)},
-			},
+			}},
 		}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod()
@@ -203,12 +203,12 @@ var _ = Describe("Instance Type Selection", func() {
 		ExpectInstancesWithLabel(supportedInstanceTypes(cloudProv.CreateCalls[0]), v1.LabelOSStable, string(v1.Linux))
 	})
 	It("should schedule on one of the cheapest instances (prov zone = test-zone-2)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelTopologyZone,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{"test-zone-2"},
-			},
+			}},
 		}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod()
@@ -231,12 +231,12 @@ var _ = Describe("Instance Type Selection", func() {
 		ExpectInstancesWithLabel(supportedInstanceTypes(cloudProv.CreateCalls[0]), v1.LabelTopologyZone, "test-zone-2")
 	})
 	It("should schedule on one of the cheapest instances (prov ct = spot)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1alpha5.LabelCapacityType,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{v1alpha5.CapacityTypeSpot},
-			},
+			}},
 		}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod()
@@ -259,17 +259,17 @@ var _ = Describe("Instance Type Selection", func() {
 		ExpectInstancesWithLabel(supportedInstanceTypes(cloudProv.CreateCalls[0]), v1alpha5.LabelCapacityType, v1alpha5.CapacityTypeSpot)
 	})
 	It("should schedule on one of the cheapest instances (prov ct = ondemand, prov zone = test-zone-1)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1alpha5.LabelCapacityType,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{v1alpha5.CapacityTypeOnDemand},
-			},
-			{
+			}},
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelTopologyZone,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{"test-zone-1"},
-			},
+			}},
 		}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod()
@@ -298,12 +298,12 @@ var _ = Describe("Instance Type Selection", func() {
 		ExpectInstancesWithOffering(supportedInstanceTypes(cloudProv.CreateCalls[0]), v1alpha5.CapacityTypeSpot, "test-zone-1")
 	})
 	It("should schedule on one of the cheapest instances (prov ct = spot, pod zone = test-zone-2)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1alpha5.LabelCapacityType,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{v1alpha5.CapacityTypeSpot},
-			},
+			}},
 		}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod(
@@ -318,27 +318,27 @@ var _ = Describe("Instance Type Selection", func() {
 		ExpectInstancesWithOffering(supportedInstanceTypes(cloudProv.CreateCalls[0]), v1alpha5.CapacityTypeSpot, "test-zone-2")
 	})
 	It("should schedule on one of the cheapest instances (prov ct = ondemand/test-zone-1/arm64/windows)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelArchStable,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{v1alpha5.ArchitectureArm64},
-			},
-			{
+			}},
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelOSStable,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{string(v1.Windows)},
-			},
-			{
+			}},
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1alpha5.LabelCapacityType,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{v1alpha5.CapacityTypeOnDemand},
-			},
-			{
+			}},
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelTopologyZone,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{"test-zone-1"},
-			},
+			}},
 		}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod()
@@ -350,17 +350,17 @@ var _ = Describe("Instance Type Selection", func() {
 		ExpectInstancesWithLabel(supportedInstanceTypes(cloudProv.CreateCalls[0]), v1.LabelArchStable, "arm64")
 	})
 	It("should schedule on one of the cheapest instances (prov = spot/test-zone-2, pod = amd64/linux)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelArchStable,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{v1alpha5.ArchitectureAmd64},
-			},
-			{
+			}},
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelOSStable,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{string(v1.Linux)},
-			},
+			}},
 		}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod(
@@ -475,12 +475,12 @@ var _ = Describe("Instance Type Selection", func() {
 			return true
 		})
 
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1.LabelArchStable,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{v1alpha5.ArchitectureArm64},
-			},
+			}},
 		}
 		Expect(len(cloudProv.InstanceTypes)).To(BeNumerically(">", 0))
 		ExpectApplied(ctx, env.Client, provisioner)
@@ -579,12 +579,12 @@ var _ = Describe("Instance Type Selection", func() {
 				},
 			}),
 		}
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-			{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key:      v1alpha5.LabelCapacityType,
 				Operator: v1.NodeSelectorOpIn,
 				Values:   []string{"on-demand"},
-			},
+			}},
 		}
 
 		ExpectApplied(ctx, env.Client, provisioner)
@@ -593,6 +593,43 @@ var _ = Describe("Instance Type Selection", func() {
 		node := ExpectScheduled(ctx, env.Client, pod)
 		Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("test-instance1"))
 	})
+	It("should launch with at least the minimum number of instance types", func() {
+		provisioner.Spec.Requirements = append(provisioner.Spec.Requirements, v1alpha5.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpExists},
+			MinValues:               lo.ToPtr(2),
+		})
+		ExpectApplied(ctx, env.Client, provisioner)
+		pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{{
+			Key:      v1.LabelInstanceTypeStable,
+			Operator: v1.NodeSelectorOpIn,
+			Values:   []string{cloudProv.InstanceTypes[0].Name, cloudProv.InstanceTypes[1].Name},
+		}}})
+		ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+		Expect(supportedInstanceTypes(cloudProv.CreateCalls[0])).To(HaveLen(2))
+	})
+	It("should not schedule if the instance types are narrower than the minimum values", func() {
+		provisioner.Spec.Requirements = append(provisioner.Spec.Requirements, v1alpha5.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpExists},
+			MinValues:               lo.ToPtr(3),
+		})
+		ExpectApplied(ctx, env.Client, provisioner)
+		pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{{
+			Key:      v1.LabelInstanceTypeStable,
+			Operator: v1.NodeSelectorOpIn,
+			Values:   []string{cloudProv.InstanceTypes[0].Name, cloudProv.InstanceTypes[1].Name},
+		}}})
+		ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should not schedule if the instance types span fewer values of another key than the minimum values", func() {
+		// the provisioner allows both architectures
+		provisioner.Spec.Requirements[0].MinValues = lo.ToPtr(2)
+		ExpectApplied(ctx, env.Client, provisioner)
+		pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1.LabelArchStable: v1alpha5.ArchitectureAmd64}})
+		ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
 })
 
 func supportedInstanceTypes(machine *v1alpha5.Machine) (res []*cloudprovider.InstanceType) {
@@ -22,8 +22,8 @@ import (
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
-	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
 	"github.com/aws/karpenter-core/pkg/cloudprovider"
 	"github.com/aws/karpenter-core/pkg/scheduling"
 	"github.com/aws/karpenter-core/pkg/utils/resources"
@@ -59,7 +59,28 @@ func NewMachine(machineTemplate *MachineTemplate, topology *Topology, daemonReso
 	}
 }
 
+// NewEmptyMachine returns a machine without pods that can be launched with any of the instance types that are compatible
+// with the template, e.g. to keep capacity running ahead of demand. Pods can't be added to the machine.
+func NewEmptyMachine(machineTemplate *MachineTemplate, instanceTypes []*cloudprovider.InstanceType) (*Machine, error) {
+	template := *machineTemplate
+	template.Requirements = scheduling.NewRequirements(machineTemplate.Requirements.Values()...)
+	template.InstanceTypeOptions = filterInstanceTypesByRequirements(instanceTypes, template.Requirements, nil)
+	if len(template.InstanceTypeOptions) == 0 {
+		return nil, fmt.Errorf("no instance type satisfied requirements %s", template.Requirements)
+	}
+	if err := ValidateMinValues(template.InstanceTypeOptions, template.MinValues); err != nil {
+		return nil, fmt.Errorf("instance types satisfying requirements %s are not flexible enough, %w", template.Requirements, err)
+	}
+	return &Machine{
+		MachineTemplate: template,
+		hostPortUsage:   scheduling.NewHostPortUsage(),
+	}, nil
+}
+
 func (m *Machine) Add(ctx context.Context, pod *v1.Pod) error {
+	if m.topology == nil {
+		return fmt.Errorf("machine doesn't accept pods")
+	}
 	// Check Taints
 	if err := m.Taints.Tolerates(pod); err != nil {
 		return err
@@ -107,11 +128,53 @@ func (m *Machine) Add(ctx context.Context, pod *v1.Pod) error {
 }
 
 // FinalizeScheduling is called once all scheduling has completed and allows the node to perform any cleanup
-// necessary before its requirements are used for instance launching
-func (m *Machine) FinalizeScheduling() {
+// necessary before its requirements are used for instance launching. It returns an error if the machine shouldn't be
+// launched, as its instance type options are narrower than the minimum values of its provisioner's requirements.
+func (m *Machine) FinalizeScheduling() error {
 	// We need nodes to have hostnames for topology purposes, but we don't want to pass that node name on to consumers
 	// of the node as it will be displayed in error messages
 	delete(m.Requirements, v1.LabelHostname)
+	if err := ValidateMinValues(m.InstanceTypeOptions, m.MinValues); err != nil {
+		return fmt.Errorf("instance types satisfying resources %s and requirements %s are not flexible enough, %w", resources.String(m.Requests), m.Requirements, err)
+	}
+	return nil
+}
+
+// FilterInstanceTypeOptions drops the instance type options that are no longer compatible with the requirements of the
+// machine, e.g. after its requirements were narrowed down at launch. It returns an error if no option remains, or if
+// the remaining options are narrower than the minimum values of its provisioner's requirements.
+func (m *Machine) FilterInstanceTypeOptions() error {
+	m.InstanceTypeOptions = filterInstanceTypesByRequirements(m.InstanceTypeOptions, m.Requirements, m.Requests)
+	if len(m.InstanceTypeOptions) == 0 {
+		return fmt.Errorf("no instance type satisfied resources %s and requirements %s", resources.String(m.Requests), m.Requirements)
+	}
+	if err := ValidateMinValues(m.InstanceTypeOptions, m.MinValues); err != nil {
+		return fmt.Errorf("instance types satisfying resources %s and requirements %s are not flexible enough, %w", resources.String(m.Requests), m.Requirements, err)
+	}
+	return nil
+}
+
+// MaxCapacity returns the largest capacity of each resource across the instance type options of the machine
+func (m *Machine) MaxCapacity() v1.ResourceList {
+	return resources.MaxResources(lo.Map(m.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) v1.ResourceList { return it.Capacity })...)
+}
+
+// Allows returns true if the requirements of the machine allow the label
+func (m *Machine) Allows(key, value string) bool {
+	return m.Requirements.Get(key).Has(value)
+}
+
+// Exclude narrows the requirements of the machine so that it doesn't launch with the label, e.g. in a zone whose
+// limits have been exceeded, and drops the instance type options that are only offered with the label
+func (m *Machine) Exclude(key, value string) error {
+	if requirement := m.Requirements.Get(key); requirement.Len() == 1 && requirement.Has(value) {
+		return fmt.Errorf("requirements only allow %s=%s", key, value)
+	}
+	m.Requirements.Add(scheduling.NewRequirement(key, v1.NodeSelectorOpNotIn, value))
+	if err := m.FilterInstanceTypeOptions(); err != nil {
+		return fmt.Errorf("excluding %s=%s, %w", key, value, err)
+	}
+	return nil
 }
 
 func (m *Machine) String() string {
@@ -140,6 +203,23 @@ func filterInstanceTypesByRequirements(instanceTypes []*cloudprovider.InstanceTy
 	})
 }
 
+// ValidateMinValues returns an error if the instance types span fewer distinct values than the minimum for any of the
+// requirement keys
+func ValidateMinValues(instanceTypes []*cloudprovider.InstanceType, minValues map[string]int) error {
+	for key, min := range minValues {
+		values := sets.NewString()
+		for _, it := range instanceTypes {
+			if requirement := it.Requirements.Get(key); requirement.Operator() == v1.NodeSelectorOpIn {
+				values.Insert(requirement.Values()...)
+			}
+		}
+		if values.Len() < min {
+			return fmt.Errorf("minimum of %d values for %s not met, only %d values remain", min, key, values.Len())
+		}
+	}
+	return nil
+}
+
 func compatible(instanceType *cloudprovider.InstanceType, requirements scheduling.Requirements) bool {
 	return instanceType.Requirements.Intersects(requirements) == nil
 }
@@ -149,11 +229,5 @@ func fits(instanceType *cloudprovider.InstanceType, requests v1.ResourceList) bo
 }
 
 func hasOffering(instanceType *cloudprovider.InstanceType, requirements scheduling.Requirements) bool {
-	for _, offering := range instanceType.Offerings.Available() {
-		if (!requirements.Has(v1.LabelTopologyZone) || requirements.Get(v1.LabelTopologyZone).Has(offering.Zone)) &&
-			(!requirements.Has(v1alpha5.LabelCapacityType) || requirements.Get(v1alpha5.LabelCapacityType).Has(offering.CapacityType)) {
-			return true
-		}
-	}
-	return false
+	return len(instanceType.Offerings.Available().Requirements(requirements)) > 0
 }
@@ -39,6 +39,7 @@ type MachineTemplate struct {
 	Taints              scheduling.Taints
 	StartupTaints       scheduling.Taints
 	Requirements        scheduling.Requirements
+	MinValues           map[string]int
 	Requests            v1.ResourceList
 	Kubelet             *v1alpha5.KubeletConfiguration
 }
@@ -46,21 +47,34 @@ type MachineTemplate struct {
 func NewMachineTemplate(provisioner *v1alpha5.Provisioner) *MachineTemplate {
 	labels := lo.Assign(provisioner.Spec.Labels, map[string]string{v1alpha5.ProvisionerNameLabelKey: provisioner.Name})
 	requirements := scheduling.NewRequirements()
-	requirements.Add(scheduling.NewNodeSelectorRequirements(provisioner.Spec.Requirements...).Values()...)
+	requirements.Add(scheduling.NewNodeSelectorRequirements(provisioner.Spec.NodeSelectorRequirements()...).Values()...)
 	requirements.Add(scheduling.NewLabelRequirements(labels).Values()...)
 	return &MachineTemplate{
 		ProvisionerName: provisioner.Name,
 		Provider:        provisioner.Spec.Provider,
 		ProviderRef:     provisioner.Spec.ProviderRef,
 		Kubelet:         provisioner.Spec.KubeletConfiguration,
-		Annotations:     provisioner.Spec.Annotations,
+		Annotations:     lo.Assign(provisioner.Spec.Annotations, map[string]string{v1alpha5.ProvisionerHashAnnotationKey: provisioner.Hash()}),
 		Labels:          labels,
 		Taints:          provisioner.Spec.Taints,
 		StartupTaints:   provisioner.Spec.StartupTaints,
 		Requirements:    requirements,
+		MinValues:       minValues(provisioner),
 	}
 }
 
+// minValues returns the minimum values of the provisioner's requirements by key. If a key has several requirements
+// with minimum values, the largest one applies.
+func minValues(provisioner *v1alpha5.Provisioner) map[string]int {
+	minValues := map[string]int{}
+	for _, requirement := range provisioner.Spec.Requirements {
+		if requirement.MinValues != nil && *requirement.MinValues > minValues[requirement.Key] {
+			minValues[requirement.Key] = *requirement.MinValues
+		}
+	}
+	return minValues
+}
+
 func (i *MachineTemplate) ToNode() *v1.Node {
 	return &v1.Node{
 		ObjectMeta: metav1.ObjectMeta{
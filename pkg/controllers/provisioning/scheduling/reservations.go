@@ -0,0 +1,94 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
+	"github.com/aws/karpenter-core/pkg/scheduling"
+)
+
+// Reservations tracks the capacity that is left in the reserved offerings while new machines are scheduled, so that
+// machines are launched into reservations until they are exhausted
+type Reservations struct {
+	remaining map[string]int
+}
+
+func NewReservations(instanceTypes map[string][]*cloudprovider.InstanceType) *Reservations {
+	r := &Reservations{remaining: map[string]int{}}
+	for _, its := range instanceTypes {
+		for _, it := range its {
+			for _, o := range it.Offerings.Available() {
+				if o.CapacityType == v1alpha5.CapacityTypeReserved {
+					r.remaining[r.key(it, o)] = o.ReservationCapacity
+				}
+			}
+		}
+	}
+	return r
+}
+
+// Reserve restricts the machine to the cheapest reserved offering that it is compatible with and that has capacity
+// left. If the reservations that it is compatible with were used up by the machines that were scheduled before it, the
+// machine is restricted to the other capacity types that it allows.
+func (r *Reservations) Reserve(m *Machine) {
+	capacityTypes := m.Requirements.Get(v1alpha5.LabelCapacityType)
+	if !capacityTypes.Has(v1alpha5.CapacityTypeReserved) {
+		return
+	}
+	var instanceType *cloudprovider.InstanceType
+	var offering cloudprovider.Offering
+	exhausted := false
+	for _, it := range m.InstanceTypeOptions {
+		// we can't narrow the machine down to a single instance type if it has to be more flexible than that
+		if ValidateMinValues([]*cloudprovider.InstanceType{it}, m.MinValues) != nil {
+			continue
+		}
+		for _, o := range it.Offerings.Available() {
+			if o.CapacityType != v1alpha5.CapacityTypeReserved || !o.Compatible(m.Requirements) {
+				continue
+			}
+			if r.remaining[r.key(it, o)] <= 0 {
+				exhausted = true
+				continue
+			}
+			if instanceType == nil || o.Price < offering.Price {
+				instanceType, offering = it, o
+			}
+		}
+	}
+	if instanceType == nil {
+		if exhausted && capacityTypes.Len() > 1 {
+			m.Requirements.Add(scheduling.NewRequirement(v1alpha5.LabelCapacityType, v1.NodeSelectorOpNotIn, v1alpha5.CapacityTypeReserved))
+		}
+		return
+	}
+	r.remaining[r.key(instanceType, offering)]--
+	m.InstanceTypeOptions = []*cloudprovider.InstanceType{instanceType}
+	m.Requirements.Add(
+		scheduling.NewRequirement(v1.LabelInstanceTypeStable, v1.NodeSelectorOpIn, instanceType.Name),
+		scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, offering.Zone),
+		scheduling.NewRequirement(v1alpha5.LabelCapacityType, v1.NodeSelectorOpIn, v1alpha5.CapacityTypeReserved),
+	)
+	m.Requirements.Add(offering.Requirements.Values()...)
+}
+
+func (r *Reservations) key(it *cloudprovider.InstanceType, o cloudprovider.Offering) string {
+	return fmt.Sprintf("%s/%v", it.Name, o.Labels())
+}
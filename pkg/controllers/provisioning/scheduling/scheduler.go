@@ -67,10 +67,19 @@ func NewScheduler(ctx context.Context, kubeClient client.Client, machines []*Mac
 		opts:               opts,
 		preferences:        &Preferences{ToleratePreferNoSchedule: toleratePreferNoSchedule},
 		remainingResources: map[string]v1.ResourceList{},
+		remainingNodes:     map[string]int64{},
+		remainingScoped:    map[string][]*scopedLimit{},
+		reservations:       NewReservations(instanceTypes),
 	}
 	for _, provisioner := range provisioners {
 		if provisioner.Spec.Limits != nil {
 			s.remainingResources[provisioner.Name] = provisioner.Spec.Limits.Resources
+			if provisioner.Spec.Limits.Nodes != nil {
+				s.remainingNodes[provisioner.Name] = *provisioner.Spec.Limits.Nodes
+			}
+			for i := range provisioner.Spec.Limits.Scoped {
+				s.remainingScoped[provisioner.Name] = append(s.remainingScoped[provisioner.Name], newScopedLimit(&provisioner.Spec.Limits.Scoped[i]))
+			}
 		}
 	}
 	s.calculateExistingMachines(stateNodes, daemonSetPods)
@@ -83,6 +92,9 @@ type Scheduler struct {
 	existingNodes      []*ExistingNode
 	machineTemplates   []*MachineTemplate
 	remainingResources map[string]v1.ResourceList // provisioner name -> remaining resources for that provisioner
+	remainingNodes     map[string]int64           // provisioner name -> remaining node count for that provisioner
+	remainingScoped    map[string][]*scopedLimit  // provisioner name -> remaining resources and nodes per zone or capacity type
+	reservations       *Reservations
 	instanceTypes      map[string][]*cloudprovider.InstanceType
 	daemonOverhead     map[*MachineTemplate]v1.ResourceList
 	preferences        *Preferences
@@ -123,11 +135,23 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*v1.Pod) ([]*Machine, []*E
 		}
 	}
 
+	failedToSchedule := q.List()
+	newNodes := make([]*Machine, 0, len(s.newNodes))
 	for _, n := range s.newNodes {
-		n.FinalizeScheduling()
+		// machines that are too narrow to launch leave their pods unscheduled
+		if err := n.FinalizeScheduling(); err != nil {
+			for _, pod := range n.Pods {
+				errors[pod] = err
+			}
+			failedToSchedule = append(failedToSchedule, n.Pods...)
+			continue
+		}
+		s.reservations.Reserve(n)
+		newNodes = append(newNodes, n)
 	}
+	s.newNodes = newNodes
 	if !s.opts.SimulationMode {
-		s.recordSchedulingResults(ctx, pods, q.List(), errors)
+		s.recordSchedulingResults(ctx, pods, failedToSchedule, errors)
 	}
 	return s.newNodes, s.existingNodes, nil
 }
@@ -196,6 +220,10 @@ func (s *Scheduler) add(ctx context.Context, pod *v1.Pod) error {
 	var errs error
 	for _, nodeTemplate := range s.machineTemplates {
 		instanceTypes := s.instanceTypes[nodeTemplate.ProvisionerName]
+		if remaining, ok := s.remainingNodes[nodeTemplate.ProvisionerName]; ok && remaining <= 0 {
+			errs = multierr.Append(errs, fmt.Errorf("provisioner %q node limit has been reached", nodeTemplate.ProvisionerName))
+			continue
+		}
 		// if limits have been applied to the provisioner, ensure we filter instance types to avoid violating those limits
 		if remaining, ok := s.remainingResources[nodeTemplate.ProvisionerName]; ok {
 			instanceTypes = filterByRemainingResources(s.instanceTypes[nodeTemplate.ProvisionerName], remaining)
@@ -209,6 +237,10 @@ func (s *Scheduler) add(ctx context.Context, pod *v1.Pod) error {
 		}
 
 		node := NewMachine(nodeTemplate, s.topology, s.daemonOverhead[nodeTemplate], instanceTypes)
+		if err := s.applyScopedLimits(node); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("incompatible with provisioner %q, %w", nodeTemplate.ProvisionerName, err))
+			continue
+		}
 		if err := node.Add(ctx, pod); err != nil {
 			errs = multierr.Append(errs, fmt.Errorf("incompatible with provisioner %q, %w", nodeTemplate.ProvisionerName, err))
 			continue
@@ -216,6 +248,15 @@ func (s *Scheduler) add(ctx context.Context, pod *v1.Pod) error {
 		// we will launch this node and need to track its maximum possible resource usage against our remaining resources
 		s.newNodes = append(s.newNodes, node)
 		s.remainingResources[nodeTemplate.ProvisionerName] = subtractMax(s.remainingResources[nodeTemplate.ProvisionerName], node.InstanceTypeOptions)
+		if _, ok := s.remainingNodes[nodeTemplate.ProvisionerName]; ok {
+			s.remainingNodes[nodeTemplate.ProvisionerName]--
+		}
+		for _, scoped := range s.remainingScoped[nodeTemplate.ProvisionerName] {
+			// the node may launch into any scope that its requirements allow, so we pessimistically charge all of them
+			if node.Requirements.Get(scoped.key).Has(scoped.value) {
+				scoped.subtract(subtractMax(scoped.resources, node.InstanceTypeOptions))
+			}
+		}
 		return nil
 	}
 	return errs
@@ -247,7 +288,40 @@ func (s *Scheduler) calculateExistingMachines(stateNodes []*state.Node, daemonSe
 		if _, ok := s.remainingResources[node.Labels()[v1alpha5.ProvisionerNameLabelKey]]; ok {
 			s.remainingResources[node.Labels()[v1alpha5.ProvisionerNameLabelKey]] = resources.Subtract(s.remainingResources[node.Labels()[v1alpha5.ProvisionerNameLabelKey]], node.Capacity())
 		}
+		if _, ok := s.remainingNodes[node.Labels()[v1alpha5.ProvisionerNameLabelKey]]; ok {
+			s.remainingNodes[node.Labels()[v1alpha5.ProvisionerNameLabelKey]]--
+		}
+		for _, scoped := range s.remainingScoped[node.Labels()[v1alpha5.ProvisionerNameLabelKey]] {
+			if node.Labels()[scoped.key] == scoped.value {
+				scoped.subtract(resources.Subtract(scoped.resources, node.Capacity()))
+			}
+		}
+	}
+}
+
+// applyScopedLimits ensures that the machine won't launch into a zone or capacity type whose limits would be exceeded
+// by it. If the machine can launch elsewhere, the scope is excluded from its requirements. Otherwise, its instance types
+// are filtered to those that fit within the remaining resources of the scope.
+func (s *Scheduler) applyScopedLimits(node *Machine) error {
+	for _, scoped := range s.remainingScoped[node.ProvisionerName] {
+		requirement := node.Requirements.Get(scoped.key)
+		if !requirement.Has(scoped.value) {
+			continue
+		}
+		instanceTypes := filterByRemainingResources(node.InstanceTypeOptions, scoped.resources)
+		if !scoped.exhausted() && len(instanceTypes) == len(node.InstanceTypeOptions) {
+			continue
+		}
+		if requirement.Len() > 1 {
+			node.Requirements.Add(scheduling.NewRequirement(scoped.key, v1.NodeSelectorOpNotIn, scoped.value))
+			continue
+		}
+		if scoped.exhausted() || len(instanceTypes) == 0 {
+			return fmt.Errorf("all available instance types exceed %s=%s provisioner limits", scoped.key, scoped.value)
+		}
+		node.InstanceTypeOptions = instanceTypes
 	}
+	return nil
 }
 
 func getDaemonOverhead(nodeTemplates []*MachineTemplate, daemonSetPods []*v1.Pod) map[*MachineTemplate]v1.ResourceList {
@@ -269,6 +343,35 @@ func getDaemonOverhead(nodeTemplates []*MachineTemplate, daemonSetPods []*v1.Pod
 	return overhead
 }
 
+// scopedLimit tracks the resources and nodes that remain within the zone or capacity type of a v1alpha5.ScopedLimit
+type scopedLimit struct {
+	key       string
+	value     string
+	resources v1.ResourceList
+	nodes     *int64
+}
+
+func newScopedLimit(limit *v1alpha5.ScopedLimit) *scopedLimit {
+	key, value := limit.Scope()
+	scoped := &scopedLimit{key: key, value: value, resources: limit.Resources}
+	if limit.Nodes != nil {
+		scoped.nodes = lo.ToPtr(*limit.Nodes)
+	}
+	return scoped
+}
+
+// subtract updates the remaining resources and removes a single node from the remaining node count
+func (l *scopedLimit) subtract(remaining v1.ResourceList) {
+	l.resources = remaining
+	if l.nodes != nil {
+		*l.nodes--
+	}
+}
+
+func (l *scopedLimit) exhausted() bool {
+	return l.nodes != nil && *l.nodes <= 0
+}
+
 // subtractMax returns the remaining resources after subtracting the max resource quantity per instance type. To avoid
 // overshooting out, we need to pessimistically assume that if e.g. we request a 2, 4 or 8 CPU instance type
 // that the 8 CPU instance type is all that will be available.  This could cause a batch of pods to take multiple rounds
@@ -50,6 +50,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 	. "knative.dev/pkg/logging/testing"
 
 	. "github.com/aws/karpenter-core/pkg/test/expectations"
@@ -161,8 +162,8 @@ var _ = Describe("Custom Constraints", func() {
 	})
 	Context("Well Known Labels", func() {
 		It("should use provisioner constraints", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-2"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-2"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod()
 			ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
@@ -170,8 +171,8 @@ var _ = Describe("Custom Constraints", func() {
 			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-2"))
 		})
 		It("should use node selectors", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod(
 				test.PodOptions{NodeSelector: map[string]string{v1.LabelTopologyZone: "test-zone-2"}},
@@ -189,8 +190,8 @@ var _ = Describe("Custom Constraints", func() {
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
 		It("should not schedule the pod if nodeselector unknown", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod(
 				test.PodOptions{NodeSelector: map[string]string{v1.LabelTopologyZone: "unknown"}},
@@ -199,8 +200,8 @@ var _ = Describe("Custom Constraints", func() {
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
 		It("should not schedule if node selector outside of provisioner constraints", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod(
 				test.PodOptions{NodeSelector: map[string]string{v1.LabelTopologyZone: "test-zone-2"}},
@@ -220,9 +221,9 @@ var _ = Describe("Custom Constraints", func() {
 			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-3"))
 		})
 		It("should schedule compatible requirements with Operator=Gt", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{{
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key: fake.IntegerInstanceLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"8"},
-			}}
+			}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod()
 			ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
@@ -230,9 +231,9 @@ var _ = Describe("Custom Constraints", func() {
 			Expect(node.Labels).To(HaveKeyWithValue(fake.IntegerInstanceLabelKey, "16"))
 		})
 		It("should schedule compatible requirements with Operator=Lt", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{{
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 				Key: fake.IntegerInstanceLabelKey, Operator: v1.NodeSelectorOpLt, Values: []string{"8"},
-			}}
+			}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod()
 			ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
@@ -388,9 +389,11 @@ var _ = Describe("Custom Constraints", func() {
 			}
 		})
 		It("should schedule pods that have node selectors with label in restricted domains exceptions list", func() {
-			var requirements []v1.NodeSelectorRequirement
+			var requirements []v1alpha5.NodeSelectorRequirementWithMinValues
 			for domain := range v1alpha5.LabelDomainExceptions {
-				requirements = append(requirements, v1.NodeSelectorRequirement{Key: domain + "/test", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}})
+				requirements = append(requirements, v1alpha5.NodeSelectorRequirementWithMinValues{
+					NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: domain + "/test", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}},
+				})
 			}
 			provisioner.Spec.Requirements = requirements
 			ExpectApplied(ctx, env.Client, provisioner)
@@ -461,8 +464,8 @@ var _ = Describe("Custom Constraints", func() {
 			Expect(node.Labels).ToNot(HaveKey("test-key"))
 		})
 		It("should schedule unconstrained pods that don't have matching node selectors", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod()
 			ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
@@ -470,8 +473,8 @@ var _ = Describe("Custom Constraints", func() {
 			Expect(node.Labels).To(HaveKeyWithValue("test-key", "test-value"))
 		})
 		It("should schedule pods that have node selectors with matching value and In operator", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod(
 				test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
@@ -482,8 +485,8 @@ var _ = Describe("Custom Constraints", func() {
 			Expect(node.Labels).To(HaveKeyWithValue("test-key", "test-value"))
 		})
 		It("should not schedule pods that have node selectors with matching value and NotIn operator", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod(
 				test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
@@ -493,8 +496,8 @@ var _ = Describe("Custom Constraints", func() {
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
 		It("should schedule the pod with Exists operator and defined key", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod(
 				test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
@@ -505,8 +508,8 @@ var _ = Describe("Custom Constraints", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 		})
 		It("should not schedule the pod with DoesNotExists operator and defined key", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod(
 				test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
@@ -517,8 +520,8 @@ var _ = Describe("Custom Constraints", func() {
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
 		It("should not schedule pods that have node selectors with different value and In operator", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod(
 				test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
@@ -528,8 +531,8 @@ var _ = Describe("Custom Constraints", func() {
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
 		It("should schedule pods that have node selectors with different value and NotIn operator", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pod := test.UnschedulablePod(
 				test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
@@ -540,8 +543,8 @@ var _ = Describe("Custom Constraints", func() {
 			Expect(node.Labels).To(HaveKeyWithValue("test-key", "test-value"))
 		})
 		It("should schedule compatible pods to the same node", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value", "another-value"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value", "another-value"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pods := []*v1.Pod{
 				test.UnschedulablePod(
@@ -560,8 +563,8 @@ var _ = Describe("Custom Constraints", func() {
 			Expect(node1.Name).To(Equal(node2.Name))
 		})
 		It("should schedule incompatible pods to the different node", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value", "another-value"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test-key", Operator: v1.NodeSelectorOpIn, Values: []string{"test-value", "another-value"}}}}
 			ExpectApplied(ctx, env.Client, provisioner)
 			pods := []*v1.Pod{
 				test.UnschedulablePod(
@@ -596,9 +599,9 @@ var _ = Describe("Custom Constraints", func() {
 var _ = Describe("Preferential Fallback", func() {
 	Context("Required", func() {
 		It("should not relax the final term", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1"}},
-				{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"default-instance-type"}},
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"default-instance-type"}}},
 			}
 			pod := test.UnschedulablePod()
 			pod.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{
@@ -655,8 +658,8 @@ var _ = Describe("Preferential Fallback", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 		})
 		It("should relax to use lighter weights", func() {
-			provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{
-				{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}}
+			provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}}}
 			pod := test.UnschedulablePod()
 			pod.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{PreferredDuringSchedulingIgnoredDuringExecution: []v1.PreferredSchedulingTerm{
 				{
@@ -727,11 +730,11 @@ var _ = Describe("Instance Type Compatibility", func() {
 		ExpectNotScheduled(ctx, env.Client, pod)
 	})
 	It("should launch pods with different archs on different instances", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 			Key:      v1.LabelArchStable,
 			Operator: v1.NodeSelectorOpIn,
 			Values:   []string{v1alpha5.ArchitectureArm64, v1alpha5.ArchitectureAmd64},
-		}}
+		}}}
 		nodeNames := sets.NewString()
 		ExpectApplied(ctx, env.Client, provisioner)
 		pods := []*v1.Pod{
@@ -750,11 +753,11 @@ var _ = Describe("Instance Type Compatibility", func() {
 		Expect(nodeNames.Len()).To(Equal(2))
 	})
 	It("should exclude instance types that are not supported by the pod constraints (node affinity/instance type)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 			Key:      v1.LabelArchStable,
 			Operator: v1.NodeSelectorOpIn,
 			Values:   []string{v1alpha5.ArchitectureAmd64},
-		}}
+		}}}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod(test.PodOptions{
 			NodeRequirements: []v1.NodeSelectorRequirement{
@@ -769,11 +772,11 @@ var _ = Describe("Instance Type Compatibility", func() {
 		ExpectNotScheduled(ctx, env.Client, pod)
 	})
 	It("should exclude instance types that are not supported by the pod constraints (node affinity/operating system)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 			Key:      v1.LabelArchStable,
 			Operator: v1.NodeSelectorOpIn,
 			Values:   []string{v1alpha5.ArchitectureAmd64},
-		}}
+		}}}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod(test.PodOptions{
 			NodeRequirements: []v1.NodeSelectorRequirement{
@@ -789,11 +792,11 @@ var _ = Describe("Instance Type Compatibility", func() {
 		ExpectNotScheduled(ctx, env.Client, pod)
 	})
 	It("should exclude instance types that are not supported by the provider constraints (arch)", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 			Key:      v1.LabelArchStable,
 			Operator: v1.NodeSelectorOpIn,
 			Values:   []string{v1alpha5.ArchitectureAmd64},
-		}}
+		}}}
 		ExpectApplied(ctx, env.Client, provisioner)
 		pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
 			Limits: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("14")}}})
@@ -802,11 +805,11 @@ var _ = Describe("Instance Type Compatibility", func() {
 		ExpectNotScheduled(ctx, env.Client, pod)
 	})
 	It("should launch pods with different operating systems on different instances", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 			Key:      v1.LabelArchStable,
 			Operator: v1.NodeSelectorOpIn,
 			Values:   []string{v1alpha5.ArchitectureArm64, v1alpha5.ArchitectureAmd64},
-		}}
+		}}}
 		nodeNames := sets.NewString()
 		ExpectApplied(ctx, env.Client, provisioner)
 		pods := []*v1.Pod{
@@ -825,11 +828,11 @@ var _ = Describe("Instance Type Compatibility", func() {
 		Expect(nodeNames.Len()).To(Equal(2))
 	})
 	It("should launch pods with different instance type node selectors on different instances", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 			Key:      v1.LabelArchStable,
 			Operator: v1.NodeSelectorOpIn,
 			Values:   []string{v1alpha5.ArchitectureArm64, v1alpha5.ArchitectureAmd64},
-		}}
+		}}}
 		nodeNames := sets.NewString()
 		ExpectApplied(ctx, env.Client, provisioner)
 		pods := []*v1.Pod{
@@ -848,11 +851,11 @@ var _ = Describe("Instance Type Compatibility", func() {
 		Expect(nodeNames.Len()).To(Equal(2))
 	})
 	It("should launch pods with different zone selectors on different instances", func() {
-		provisioner.Spec.Requirements = []v1.NodeSelectorRequirement{{
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{
 			Key:      v1.LabelArchStable,
 			Operator: v1.NodeSelectorOpIn,
 			Values:   []string{v1alpha5.ArchitectureArm64, v1alpha5.ArchitectureAmd64},
-		}}
+		}}}
 		nodeNames := sets.NewString()
 		ExpectApplied(ctx, env.Client, provisioner)
 		pods := []*v1.Pod{
@@ -1249,6 +1252,98 @@ var _ = Describe("Binpacking", func() {
 		possibleInstanceType := sets.NewString(pscheduling.NewNodeSelectorRequirements(cloudProv.CreateCalls[0].Spec.Requirements...).Get(v1.LabelInstanceTypeStable).Values()...)
 		Expect(possibleInstanceType).To(Equal(sets.NewString("small", "medium", "large")))
 	})
+	It("should only select instance types with an offering that is compatible with all requirements", func() {
+		// the cheaper instance type offers the reservation and the zone, but not together
+		cloudProv.InstanceTypes = []*cloudprovider.InstanceType{
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "split",
+				Offerings: []cloudprovider.Offering{
+					{
+						CapacityType: v1alpha5.CapacityTypeOnDemand,
+						Zone:         "test-zone-1",
+						Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement("test.com/reservation-id", v1.NodeSelectorOpIn, "r-2")),
+						Price:        1.00,
+						Available:    true,
+					},
+					{
+						CapacityType: v1alpha5.CapacityTypeOnDemand,
+						Zone:         "test-zone-2",
+						Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement("test.com/reservation-id", v1.NodeSelectorOpIn, "r-1")),
+						Price:        1.00,
+						Available:    true,
+					},
+				},
+			}),
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "reserved",
+				Offerings: []cloudprovider.Offering{
+					{
+						CapacityType: v1alpha5.CapacityTypeOnDemand,
+						Zone:         "test-zone-1",
+						Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement("test.com/reservation-id", v1.NodeSelectorOpIn, "r-1")),
+						Price:        2.00,
+						Available:    true,
+					},
+				},
+			}),
+		}
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test.com/reservation-id", Operator: v1.NodeSelectorOpIn, Values: []string{"r-1"}}}}
+		ExpectApplied(ctx, env.Client, provisioner)
+		pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1.LabelTopologyZone: "test-zone-1"}})
+		ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("reserved"))
+		Expect(node.Labels).To(HaveKeyWithValue("test.com/reservation-id", "r-1"))
+	})
+})
+
+var _ = Describe("Reserved Capacity", func() {
+	BeforeEach(func() {
+		cloudProv.InstanceTypes = []*cloudprovider.InstanceType{
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "reserved",
+				Offerings: []cloudprovider.Offering{
+					{CapacityType: v1alpha5.CapacityTypeReserved, Zone: "test-zone-1", Price: 2.00, ReservationCapacity: 1, Available: true},
+					{CapacityType: v1alpha5.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 2.00, Available: true},
+				},
+			}),
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "cheap",
+				Offerings: []cloudprovider.Offering{
+					{CapacityType: v1alpha5.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 1.00, Available: true},
+				},
+			}),
+		}
+	})
+	It("should launch into a reservation that has capacity left", func() {
+		ExpectApplied(ctx, env.Client, provisioner)
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("reserved"))
+		Expect(node.Labels[v1alpha5.LabelCapacityType]).To(Equal(v1alpha5.CapacityTypeReserved))
+	})
+	It("should not launch into a reservation that has been used up", func() {
+		ExpectApplied(ctx, env.Client, provisioner)
+		// each of the pods needs a node of its own
+		pods := []*v1.Pod{
+			test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("3")}}}),
+			test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("3")}}}),
+		}
+		ExpectProvisioned(ctx, env.Client, cluster, prov, pods...)
+		capacityTypes := lo.Map(pods, func(pod *v1.Pod, _ int) string {
+			return ExpectScheduled(ctx, env.Client, pod).Labels[v1alpha5.LabelCapacityType]
+		})
+		Expect(capacityTypes).To(ConsistOf(v1alpha5.CapacityTypeReserved, v1alpha5.CapacityTypeOnDemand))
+	})
+	It("should not launch into a reservation if the provisioner doesn't allow reserved capacity", func() {
+		provisioner.Spec.Requirements = []v1alpha5.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1alpha5.LabelCapacityType, Operator: v1.NodeSelectorOpIn, Values: []string{v1alpha5.CapacityTypeOnDemand}}}}
+		ExpectApplied(ctx, env.Client, provisioner)
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[v1alpha5.LabelCapacityType]).To(Equal(v1alpha5.CapacityTypeOnDemand))
+	})
 })
 
 var _ = Describe("In-Flight Nodes", func() {
@@ -17,6 +17,7 @@ package provisioning_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -119,6 +120,24 @@ var _ = Describe("Provisioning", func() {
 		Expect(len(nodes.Items)).To(Equal(0))
 		ExpectNotScheduled(ctx, env.Client, pod)
 	})
+	It("should ignore provisioners that are paused", func() {
+		ExpectApplied(ctx, env.Client, test.Provisioner(test.ProvisionerOptions{Paused: true}))
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+		nodes := &v1.NodeList{}
+		Expect(env.Client.List(ctx, nodes)).To(Succeed())
+		Expect(len(nodes.Items)).To(Equal(0))
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should provision nodes with an unpaused provisioner when another is paused", func() {
+		paused := test.Provisioner(test.ProvisionerOptions{Paused: true, Weight: ptr.Int32(100)})
+		unpaused := test.Provisioner()
+		ExpectApplied(ctx, env.Client, paused, unpaused)
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1alpha5.ProvisionerNameLabelKey, unpaused.Name))
+	})
 	It("should provision nodes for pods with supported node selectors", func() {
 		provisioner := test.Provisioner()
 		schedulable := []*v1.Pod{
@@ -365,6 +384,163 @@ var _ = Describe("Provisioning", func() {
 			ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
+		It("should not launch more nodes than the node limit", func() {
+			provisioner := test.Provisioner()
+			provisioner.Spec.Limits.Nodes = ptr.Int64(2)
+			ExpectApplied(ctx, env.Client, provisioner)
+			opts := test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "foo"}},
+				PodAntiRequirements: []v1.PodAffinityTerm{{
+					TopologyKey:   v1.LabelHostname,
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}},
+				}},
+			}
+			pods := []*v1.Pod{test.UnschedulablePod(opts), test.UnschedulablePod(opts), test.UnschedulablePod(opts)}
+			ExpectProvisioned(ctx, env.Client, cluster, prov, pods...)
+			nodeNames := map[string]struct{}{}
+			for _, pod := range pods {
+				if pod = ExpectPodExists(ctx, env.Client, pod.Name, pod.Namespace); pod.Spec.NodeName != "" {
+					nodeNames[pod.Spec.NodeName] = struct{}{}
+				}
+			}
+			Expect(nodeNames).To(HaveLen(2))
+		})
+		It("should not launch nodes into a zone whose scoped limit is exceeded", func() {
+			provisioner := test.Provisioner()
+			provisioner.Spec.Limits.Scoped = []v1alpha5.ScopedLimit{{Zone: ptr.String("test-zone-1"), Nodes: ptr.Int64(0)}}
+			ExpectApplied(ctx, env.Client, provisioner)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelTopologyZone]).ToNot(Equal("test-zone-1"))
+		})
+		It("should not schedule pods that require a zone whose scoped limit is exceeded", func() {
+			provisioner := test.Provisioner()
+			provisioner.Spec.Limits.Scoped = []v1alpha5.ScopedLimit{{Zone: ptr.String("test-zone-1"), Nodes: ptr.Int64(0)}}
+			ExpectApplied(ctx, env.Client, provisioner)
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1.LabelTopologyZone: "test-zone-1"}})
+			ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should not launch nodes of a capacity type whose scoped resource limit would be exceeded", func() {
+			provisioner := test.Provisioner()
+			provisioner.Spec.Limits.Scoped = []v1alpha5.ScopedLimit{{
+				CapacityType: ptr.String(v1alpha5.CapacityTypeSpot),
+				Resources:    v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			}}
+			ExpectApplied(ctx, env.Client, provisioner)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1alpha5.LabelCapacityType]).To(Equal(v1alpha5.CapacityTypeOnDemand))
+		})
+		It("should not launch a machine whose instance types are only offered in scopes that exceeded their limits since scheduling", func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "single-zone-instance-type",
+				Offerings: []cloudprovider.Offering{
+					{CapacityType: v1alpha5.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 1, Available: true},
+				},
+			})}
+			provisioner := test.Provisioner(test.ProvisionerOptions{
+				Requirements: []v1.NodeSelectorRequirement{
+					{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}},
+				},
+			})
+			provisioner.Spec.Limits.Scoped = []v1alpha5.ScopedLimit{{Zone: ptr.String("test-zone-1"), Nodes: ptr.Int64(1)}}
+			ExpectApplied(ctx, env.Client, provisioner, test.UnschedulablePod())
+			machines, _, err := prov.Schedule(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machines).To(HaveLen(1))
+
+			// another node fills up the zone before the machine is launched
+			node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				v1alpha5.ProvisionerNameLabelKey: provisioner.Name,
+				v1.LabelTopologyZone:             "test-zone-1",
+			}}})
+			ExpectApplied(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			_, err = prov.LaunchMachines(ctx, machines)
+			Expect(err).To(HaveOccurred())
+			Expect(cloudProvider.CreateCalls).To(BeEmpty())
+		})
+	})
+	Context("Insufficient Capacity", func() {
+		It("should not launch with an offering that recently ran out of capacity", func() {
+			decorated := cloudprovider.DecorateWithUnavailableOfferings(cloudProvider, cloudprovider.NewUnavailableOfferings(fakeClock))
+			p := provisioning.NewProvisioner(ctx, env.Client, corev1.NewForConfigOrDie(env.Config), events.NewRecorder(&record.FakeRecorder{}), decorated, cluster)
+			ExpectApplied(ctx, env.Client, test.Provisioner(test.ProvisionerOptions{
+				Requirements: []v1.NodeSelectorRequirement{
+					{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"default-instance-type"}},
+					{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1"}},
+					{Key: v1alpha5.LabelCapacityType, Operator: v1.NodeSelectorOpIn, Values: []string{v1alpha5.CapacityTypeSpot}},
+				},
+			}))
+			cloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError("default-instance-type", "test-zone-1", v1alpha5.CapacityTypeSpot, fmt.Errorf("out of capacity"))
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, p, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+
+			// the offering is skipped rather than retried
+			ExpectProvisioned(ctx, env.Client, cluster, p, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+
+			// the offering is used again once the TTL has expired
+			fakeClock.Step(cloudprovider.UnavailableOfferingsTTL + time.Second)
+			ExpectProvisioned(ctx, env.Client, cluster, p, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(cloudProvider.CreateCalls).To(HaveLen(2))
+		})
+	})
+	Context("Batch Create", func() {
+		var pods []*v1.Pod
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, test.Provisioner(test.ProvisionerOptions{
+				Requirements: []v1.NodeSelectorRequirement{
+					{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"small-instance-type"}},
+				},
+			}))
+			// each pod needs a node of its own
+			pods = test.Pods(2, test.UnscheduleablePodOptions(test.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1.5")}},
+			}))
+			ExpectApplied(ctx, env.Client, pods[0], pods[1])
+		})
+		It("should launch machines with a single call when the cloud provider supports batch create", func() {
+			cloudProvider.SupportedCapabilities.BatchCreate = true
+			machines, _, err := prov.Schedule(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machines).To(HaveLen(2))
+			names, err := prov.LaunchMachines(ctx, machines)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProvider.CreateBatchCalls).To(HaveLen(1))
+			Expect(cloudProvider.CreateBatchCalls[0]).To(HaveLen(2))
+			for _, name := range names {
+				ExpectNodeExists(ctx, env.Client, name)
+			}
+		})
+		It("should report errors for each machine of a batch", func() {
+			cloudProvider.SupportedCapabilities.BatchCreate = true
+			cloudProvider.NextCreateErr = fmt.Errorf("failed to launch")
+			machines, _, err := prov.Schedule(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machines).To(HaveLen(2))
+			names, err := prov.LaunchMachines(ctx, machines)
+			Expect(err).To(MatchError(ContainSubstring("failed to launch")))
+			Expect(cloudProvider.CreateBatchCalls).To(HaveLen(1))
+			Expect(names[0]).To(BeEmpty())
+			ExpectNodeExists(ctx, env.Client, names[1])
+		})
+		It("should launch machines individually when the cloud provider doesn't support batch create", func() {
+			machines, _, err := prov.Schedule(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machines).To(HaveLen(2))
+			_, err = prov.LaunchMachines(ctx, machines)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProvider.CreateBatchCalls).To(BeEmpty())
+			Expect(cloudProvider.CreateCalls).To(HaveLen(2))
+		})
 	})
 	Context("Daemonsets and Node Overhead", func() {
 		It("should account for overhead", func() {
@@ -559,6 +735,14 @@ var _ = Describe("Provisioning", func() {
 			node := ExpectScheduled(ctx, env.Client, pod)
 			Expect(node.Annotations).To(HaveKeyWithValue(v1alpha5.DoNotConsolidateNodeAnnotationKey, "true"))
 		})
+		It("should stamp the provisioner hash on the node", func() {
+			provisioner := test.Provisioner()
+			ExpectApplied(ctx, env.Client, provisioner)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Annotations).To(HaveKeyWithValue(v1alpha5.ProvisionerHashAnnotationKey, provisioner.Hash()))
+		})
 	})
 	Context("Labels", func() {
 		It("should label nodes", func() {
@@ -53,6 +53,9 @@ type Cluster struct {
 
 	antiAffinityPods sync.Map // pod namespaced name -> *v1.Pod of pods that have required anti affinities
 
+	reservations map[*Reservation]struct{} // capacity reserved for machines that are being launched
+	launches     map[string]LaunchHistory  // provisioner name -> outcomes of the most recent launches
+
 	// consolidated is a dirty bit that indicates that the cluster hasn't
 	// changed since last consolidation and avoids recomputation.
 	consolidated   atomic.Bool
@@ -67,6 +70,8 @@ func NewCluster(clk clock.Clock, client client.Client, cp cloudprovider.CloudPro
 		nodes:            map[string]*Node{},
 		bindings:         map[types.NamespacedName]string{},
 		nameToProviderID: map[string]string{},
+		reservations:     map[*Reservation]struct{}{},
+		launches:         map[string]LaunchHistory{},
 	}
 }
 
@@ -293,6 +298,8 @@ func (c *Cluster) Reset() {
 	c.nameToProviderID = map[string]string{}
 	c.bindings = map[types.NamespacedName]string{}
 	c.antiAffinityPods = sync.Map{}
+	c.reservations = map[*Reservation]struct{}{}
+	c.launches = map[string]LaunchHistory{}
 }
 
 // WARNING
@@ -0,0 +1,50 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"time"
+)
+
+// LaunchHistory contains the outcomes of the most recent attempts to launch machines for a provisioner
+type LaunchHistory struct {
+	// LastLaunchTime is the last time that a machine was launched successfully
+	LastLaunchTime time.Time
+	// LastError is the error of the last failed launch
+	LastError error
+	// LastErrorTime is the last time that a launch failed
+	LastErrorTime time.Time
+}
+
+// RecordLaunch records the outcome of creating a machine for the provisioner with the cloud provider
+func (c *Cluster) RecordLaunch(provisionerName string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	history := c.launches[provisionerName]
+	if err != nil {
+		history.LastError = err
+		history.LastErrorTime = c.clock.Now()
+	} else {
+		history.LastLaunchTime = c.clock.Now()
+	}
+	c.launches[provisionerName] = history
+}
+
+// LaunchHistory returns the outcomes of the most recent launches for the provisioner
+func (c *Cluster) LaunchHistory(provisionerName string) LaunchHistory {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.launches[provisionerName]
+}
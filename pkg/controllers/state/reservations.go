@@ -0,0 +1,210 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	"github.com/aws/karpenter-core/pkg/utils/resources"
+)
+
+// settledReservationTTL is how long a settled reservation is held if the cluster state never starts tracking its node,
+// e.g. because the instance was terminated before it registered.
+const settledReservationTTL = 5 * time.Minute
+
+// Reservation is capacity that has been debited from a provisioner's limits for a machine that is being launched. The
+// reservation is held until the machine is tracked by the cluster state, at which point its capacity is accounted for
+// by the node itself.
+type Reservation struct {
+	provisionerName string
+	resources       v1.ResourceList
+	// scopes are the labels of the scoped limits that the machine may launch with, e.g. topology.kubernetes.io/zone=a
+	scopes     sets.String
+	providerID string
+	settledAt  time.Time
+}
+
+// ReservableMachine is a machine that capacity is reserved for before it's launched
+type ReservableMachine interface {
+	// MaxCapacity returns the largest capacity that the machine could launch with
+	MaxCapacity() v1.ResourceList
+	// Allows returns true if the machine could launch with the label, e.g. in the zone
+	Allows(key, value string) bool
+	// Exclude narrows the machine so that it doesn't launch with the label. It returns an error if the machine can't
+	// launch otherwise.
+	Exclude(key, value string) error
+}
+
+// Reserve debits the largest capacity that a single machine could launch with from the limits of the provisioner.
+// Nodes that are being tracked by the cluster state and the outstanding reservations of the provisioner are considered
+// so that concurrent launches can't exceed the limits. The zones and capacity types whose scoped limits would be
+// exceeded are excluded from the machine, and no reservation is made if the limits would be exceeded otherwise. The
+// nodes that the machine replaces, e.g. when consolidating, aren't counted as they are deleted once the machine has
+// launched.
+func (c *Cluster) Reserve(provisioner *v1alpha5.Provisioner, machine ReservableMachine, replacedNodeNames ...string) (*Reservation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cleanupReservations()
+	excluded := sets.NewString(replacedNodeNames...)
+	if err := c.applyScopedLimits(provisioner, machine, excluded); err != nil {
+		return nil, fmt.Errorf("reserving capacity, %w", err)
+	}
+	res := machine.MaxCapacity()
+	usage, nodes := c.usageFor(provisioner.Name, excluded)
+	usage = resources.Merge(usage, res)
+	if err := provisioner.Spec.Limits.ExceededBy(usage); err != nil {
+		return nil, fmt.Errorf("reserving capacity, %w", err)
+	}
+	if err := provisioner.Spec.Limits.ExceededByNodes(nodes + 1); err != nil {
+		return nil, fmt.Errorf("reserving capacity, %w", err)
+	}
+	reservation := &Reservation{provisionerName: provisioner.Name, resources: res, scopes: scopesOf(provisioner, machine)}
+	c.reservations[reservation] = struct{}{}
+	return reservation, nil
+}
+
+// Release returns the reserved resources to the provisioner, e.g. if the machine failed to launch
+func (c *Cluster) Release(reservation *Reservation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.reservations, reservation)
+}
+
+// Settle marks the machine of the reservation as launched. The reservation is held until the cluster state tracks the
+// node with the provider id.
+func (c *Cluster) Settle(reservation *Reservation, providerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.reservations[reservation]; !ok {
+		return
+	}
+	reservation.providerID = providerID
+	reservation.settledAt = c.clock.Now()
+	c.cleanupReservations()
+}
+
+// Reserved returns the resources and the number of nodes that are currently reserved for the provisioner
+func (c *Cluster) Reserved(provisionerName string) (v1.ResourceList, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cleanupReservations()
+	var res v1.ResourceList
+	var nodes int64
+	for r := range c.reservations {
+		if r.provisionerName == provisionerName {
+			res = resources.Merge(res, r.resources)
+			nodes++
+		}
+	}
+	return res, nodes
+}
+
+// usageFor returns the capacity and the number of nodes of the provisioner, including outstanding reservations. Nodes
+// that are marked for deletion are not counted, consistent with the counter controller, and neither are the excluded
+// nodes.
+func (c *Cluster) usageFor(provisionerName string, excluded sets.String) (v1.ResourceList, int64) {
+	var usage v1.ResourceList
+	var nodes int64
+	for _, n := range c.nodes {
+		if n.MarkedForDeletion() || excluded.Has(n.Name()) || n.Labels()[v1alpha5.ProvisionerNameLabelKey] != provisionerName {
+			continue
+		}
+		usage = resources.Merge(usage, n.Capacity())
+		nodes++
+	}
+	for r := range c.reservations {
+		if r.provisionerName == provisionerName {
+			usage = resources.Merge(usage, r.resources)
+			nodes++
+		}
+	}
+	return usage, nodes
+}
+
+// applyScopedLimits excludes the zones and capacity types whose scoped limits the machine would exceed from the machine
+func (c *Cluster) applyScopedLimits(provisioner *v1alpha5.Provisioner, machine ReservableMachine, excluded sets.String) error {
+	if provisioner.Spec.Limits == nil {
+		return nil
+	}
+	for i := range provisioner.Spec.Limits.Scoped {
+		limit := &provisioner.Spec.Limits.Scoped[i]
+		key, value := limit.Scope()
+		if !machine.Allows(key, value) {
+			continue
+		}
+		usage, nodes := c.scopedUsageFor(provisioner.Name, key, value, excluded)
+		// account for the machine that we are about to launch
+		if err := limit.ExceededBy(resources.Merge(usage, machine.MaxCapacity()), nodes+1); err != nil {
+			if excludeErr := machine.Exclude(key, value); excludeErr != nil {
+				return fmt.Errorf("%s, %w", err, excludeErr)
+			}
+		}
+	}
+	return nil
+}
+
+// scopedUsageFor returns the capacity and the number of nodes of the provisioner that have the label, including the
+// outstanding reservations of the machines that may launch with the label
+func (c *Cluster) scopedUsageFor(provisionerName string, key, value string, excluded sets.String) (v1.ResourceList, int64) {
+	var usage v1.ResourceList
+	var nodes int64
+	for _, n := range c.nodes {
+		if n.MarkedForDeletion() || excluded.Has(n.Name()) || n.Labels()[v1alpha5.ProvisionerNameLabelKey] != provisionerName ||
+			n.Labels()[key] != value {
+			continue
+		}
+		usage = resources.Merge(usage, n.Capacity())
+		nodes++
+	}
+	for r := range c.reservations {
+		if r.provisionerName == provisionerName && r.scopes.Has(key+"="+value) {
+			usage = resources.Merge(usage, r.resources)
+			nodes++
+		}
+	}
+	return usage, nodes
+}
+
+// scopesOf returns the labels of the scoped limits of the provisioner that the machine may launch with
+func scopesOf(provisioner *v1alpha5.Provisioner, machine ReservableMachine) sets.String {
+	scopes := sets.NewString()
+	if provisioner.Spec.Limits == nil {
+		return scopes
+	}
+	for i := range provisioner.Spec.Limits.Scoped {
+		if key, value := provisioner.Spec.Limits.Scoped[i].Scope(); machine.Allows(key, value) {
+			scopes.Insert(key + "=" + value)
+		}
+	}
+	return scopes
+}
+
+// cleanupReservations removes settled reservations whose nodes are tracked by the cluster state or that have expired
+func (c *Cluster) cleanupReservations() {
+	for r := range c.reservations {
+		if r.settledAt.IsZero() {
+			continue
+		}
+		if _, ok := c.nodes[r.providerID]; ok || c.clock.Since(r.settledAt) > settledReservationTTL {
+			delete(c.reservations, r)
+		}
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/aws/karpenter-core/pkg/operator/controller"
 	"github.com/aws/karpenter-core/pkg/operator/scheme"
 
+	"github.com/aws/karpenter-core/pkg/cloudprovider"
 	"github.com/aws/karpenter-core/pkg/cloudprovider/fake"
 
 	v1 "k8s.io/api/core/v1"
@@ -39,6 +40,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/aws/karpenter-core/pkg/controllers/state"
+	"github.com/aws/karpenter-core/pkg/scheduling"
 	"github.com/aws/karpenter-core/pkg/test"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -1226,6 +1228,157 @@ var _ = Describe("Provisioner Spec Updates", func() {
 	})
 })
 
+var _ = Describe("Reservations", func() {
+	var instanceType *cloudprovider.InstanceType
+	BeforeEach(func() {
+		instanceType = cloudProvider.InstanceTypes[0]
+		// the limits allow for exactly two nodes of the instance type
+		provisioner.Spec.Limits = &v1alpha5.Limits{Resources: v1.ResourceList{
+			v1.ResourceCPU: *resource.NewQuantity(instanceType.Capacity.Cpu().Value()*2, resource.DecimalSI),
+		}}
+	})
+	It("should reserve capacity within the provisioner limits", func() {
+		_, err := cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).To(HaveOccurred())
+
+		reserved, nodes := cluster.Reserved(provisioner.Name)
+		Expect(nodes).To(BeNumerically("==", 2))
+		Expect(reserved.Cpu().Value()).To(Equal(instanceType.Capacity.Cpu().Value() * 2))
+	})
+	It("should count tracked nodes against the provisioner limits", func() {
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				v1alpha5.ProvisionerNameLabelKey: provisioner.Name,
+				v1.LabelInstanceTypeStable:       instanceType.Name,
+			}},
+			ProviderID: test.RandomProviderID(),
+		})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+		_, err := cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should not count nodes that are marked for deletion against the provisioner limits", func() {
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				v1alpha5.ProvisionerNameLabelKey: provisioner.Name,
+				v1.LabelInstanceTypeStable:       instanceType.Name,
+			}},
+			ProviderID: test.RandomProviderID(),
+		})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		cluster.MarkForDeletion(node.Name)
+
+		_, err := cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should not count the nodes that are replaced against the provisioner limits", func() {
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				v1alpha5.ProvisionerNameLabelKey: provisioner.Name,
+				v1.LabelInstanceTypeStable:       instanceType.Name,
+			}},
+			ProviderID: test.RandomProviderID(),
+		})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+		_, err := cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity), node.Name)
+		Expect(err).ToNot(HaveOccurred())
+		// the node is still counted for launches that don't replace it
+		_, err = cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).To(HaveOccurred())
+		_, err = cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity), node.Name)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should count reservations against the node limit", func() {
+		provisioner.Spec.Limits.Nodes = ptr.Int64(1)
+		_, err := cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should return the reserved capacity when released", func() {
+		reservation, err := cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).ToNot(HaveOccurred())
+		cluster.Release(reservation)
+		_, err = cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should hold a settled reservation until the node is tracked", func() {
+		reservation, err := cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).ToNot(HaveOccurred())
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				v1alpha5.ProvisionerNameLabelKey: provisioner.Name,
+				v1.LabelInstanceTypeStable:       instanceType.Name,
+			}},
+			ProviderID: test.RandomProviderID(),
+		})
+		cluster.Settle(reservation, node.Spec.ProviderID)
+		_, nodes := cluster.Reserved(provisioner.Name)
+		Expect(nodes).To(BeNumerically("==", 1))
+
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		_, nodes = cluster.Reserved(provisioner.Name)
+		Expect(nodes).To(BeNumerically("==", 0))
+	})
+	It("should expire a settled reservation if the node is never tracked", func() {
+		reservation, err := cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity))
+		Expect(err).ToNot(HaveOccurred())
+		cluster.Settle(reservation, test.RandomProviderID())
+		_, nodes := cluster.Reserved(provisioner.Name)
+		Expect(nodes).To(BeNumerically("==", 1))
+
+		fakeClock.Step(10 * time.Minute)
+		_, nodes = cluster.Reserved(provisioner.Name)
+		Expect(nodes).To(BeNumerically("==", 0))
+	})
+	Context("Scoped Limits", func() {
+		BeforeEach(func() {
+			// the zone doesn't have room for a single node of the instance type
+			provisioner.Spec.Limits.Scoped = []v1alpha5.ScopedLimit{{
+				Zone:      ptr.String("test-zone-1"),
+				Resources: v1.ResourceList{v1.ResourceCPU: *resource.NewQuantity(instanceType.Capacity.Cpu().Value()-1, resource.DecimalSI)},
+			}}
+		})
+		It("should count the capacity of the machine against the scoped limits", func() {
+			machine := NewReservableMachine(instanceType.Capacity, scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, "test-zone-1"))
+			_, err := cluster.Reserve(provisioner, machine)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should exclude the scopes whose limits the machine would exceed", func() {
+			machine := NewReservableMachine(instanceType.Capacity, scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, "test-zone-1", "test-zone-2"))
+			_, err := cluster.Reserve(provisioner, machine)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.Allows(v1.LabelTopologyZone, "test-zone-1")).To(BeFalse())
+			Expect(machine.Allows(v1.LabelTopologyZone, "test-zone-2")).To(BeTrue())
+		})
+		It("should only count the reservations of machines that may launch within the scope", func() {
+			provisioner.Spec.Limits = &v1alpha5.Limits{Scoped: []v1alpha5.ScopedLimit{{Zone: ptr.String("test-zone-1"), Nodes: ptr.Int64(1)}}}
+			_, err := cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity, scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, "test-zone-2")))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity, scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, "test-zone-1")))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = cluster.Reserve(provisioner, NewReservableMachine(instanceType.Capacity, scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, "test-zone-1")))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
 var _ = Describe("Cluster State Sync", func() {
 	It("should consider the cluster state synced when all nodes are tracked", func() {
 		// Deploy 1000 nodes and sync them all with the cluster
@@ -1431,3 +1584,29 @@ func ExpectStateNodeNotFoundForMachine(machine *v1alpha5.Machine) *state.Node {
 	ExpectWithOffset(1, ret).To(BeNil())
 	return ret
 }
+
+// ReservableMachine is a machine that launches with a single capacity anywhere that its requirements allow
+type ReservableMachine struct {
+	capacity     v1.ResourceList
+	requirements scheduling.Requirements
+}
+
+func NewReservableMachine(capacity v1.ResourceList, requirements ...*scheduling.Requirement) *ReservableMachine {
+	return &ReservableMachine{capacity: capacity, requirements: scheduling.NewRequirements(requirements...)}
+}
+
+func (m *ReservableMachine) MaxCapacity() v1.ResourceList {
+	return m.capacity
+}
+
+func (m *ReservableMachine) Allows(key, value string) bool {
+	return m.requirements.Get(key).Has(value)
+}
+
+func (m *ReservableMachine) Exclude(key, value string) error {
+	m.requirements.Add(scheduling.NewRequirement(key, v1.NodeSelectorOpNotIn, value))
+	if m.requirements.Get(key).Len() == 0 {
+		return fmt.Errorf("requirements only allow %s=%s", key, value)
+	}
+	return nil
+}
@@ -1,3 +1,5 @@
+//go:build !ignore_autogenerated
+
 /*
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -12,8 +14,6 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-//go:build !ignore_autogenerated
-
 // Code generated by controller-gen. DO NOT EDIT.
 
 package state
@@ -19,6 +19,8 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
 )
 
 // PodNominationRateLimiter is a pointer so it rate-limits across events
@@ -74,3 +76,33 @@ func NodeInflightCheck(node *v1.Node, message string) Event {
 		DedupeValues:   []string{node.Name, message},
 	}
 }
+
+func ProvisionerPaused(provisioner *v1alpha5.Provisioner) Event {
+	return Event{
+		InvolvedObject: provisioner,
+		Type:           v1.EventTypeWarning,
+		Reason:         "Paused",
+		Message:        "Provisioner is paused, nodes won't be launched or deprovisioned",
+		DedupeValues:   []string{provisioner.Name, "paused"},
+	}
+}
+
+func ProvisionerResumed(provisioner *v1alpha5.Provisioner) Event {
+	return Event{
+		InvolvedObject: provisioner,
+		Type:           v1.EventTypeNormal,
+		Reason:         "Resumed",
+		Message:        "Provisioner is no longer paused",
+		DedupeValues:   []string{provisioner.Name, "resumed"},
+	}
+}
+
+func NodeNotification(node *v1.Node, kind string, message string) Event {
+	return Event{
+		InvolvedObject: node,
+		Type:           v1.EventTypeWarning,
+		Reason:         kind,
+		Message:        message,
+		DedupeValues:   []string{node.Name, kind},
+	}
+}
@@ -34,6 +34,7 @@ const (
 	ExpirationReason     = "expiration"
 	EmptinessReason      = "emptiness"
 	DriftReason          = "drift"
+	InterruptionReason   = "interruption"
 )
 
 // DurationBuckets returns a []float64 of default threshold values for duration histograms.
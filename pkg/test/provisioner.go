@@ -31,20 +31,24 @@ import (
 // ProvisionerOptions customizes a Provisioner.
 type ProvisionerOptions struct {
 	metav1.ObjectMeta
-	Limits                 v1.ResourceList
-	Provider               interface{}
-	ProviderRef            *v1alpha5.ProviderRef
-	Kubelet                *v1alpha5.KubeletConfiguration
-	Annotations            map[string]string
-	Labels                 map[string]string
-	Taints                 []v1.Taint
-	StartupTaints          []v1.Taint
-	Requirements           []v1.NodeSelectorRequirement
-	Status                 v1alpha5.ProvisionerStatus
-	TTLSecondsUntilExpired *int64
-	Weight                 *int32
-	TTLSecondsAfterEmpty   *int64
-	Consolidation          *v1alpha5.Consolidation
+	Limits                       v1.ResourceList
+	Provider                     interface{}
+	ProviderRef                  *v1alpha5.ProviderRef
+	Kubelet                      *v1alpha5.KubeletConfiguration
+	Annotations                  map[string]string
+	Labels                       map[string]string
+	Taints                       []v1.Taint
+	StartupTaints                []v1.Taint
+	Requirements                 []v1.NodeSelectorRequirement
+	Status                       v1alpha5.ProvisionerStatus
+	TTLSecondsUntilExpired       *int64
+	TTLSecondsUntilExpiredJitter *int64
+	Weight                       *int32
+	TTLSecondsAfterEmpty         *int64
+	Consolidation                *v1alpha5.Consolidation
+	Disruption                   *v1alpha5.Disruption
+	MinimumCapacity              *v1alpha5.MinimumCapacity
+	Paused                       bool
 }
 
 // Provisioner creates a test provisioner with defaults that can be overridden by ProvisionerOptions.
@@ -68,19 +72,25 @@ func Provisioner(overrides ...ProvisionerOptions) *v1alpha5.Provisioner {
 	provisioner := &v1alpha5.Provisioner{
 		ObjectMeta: ObjectMeta(options.ObjectMeta),
 		Spec: v1alpha5.ProvisionerSpec{
-			Requirements:           options.Requirements,
-			KubeletConfiguration:   options.Kubelet,
-			ProviderRef:            options.ProviderRef,
-			Taints:                 options.Taints,
-			StartupTaints:          options.StartupTaints,
-			Annotations:            options.Annotations,
-			Labels:                 lo.Assign(options.Labels, map[string]string{DiscoveryLabel: "unspecified"}), // For node cleanup discovery
-			Limits:                 &v1alpha5.Limits{Resources: options.Limits},
-			TTLSecondsAfterEmpty:   options.TTLSecondsAfterEmpty,
-			TTLSecondsUntilExpired: options.TTLSecondsUntilExpired,
-			Weight:                 options.Weight,
-			Consolidation:          options.Consolidation,
-			Provider:               raw,
+			Requirements: lo.Map(options.Requirements, func(r v1.NodeSelectorRequirement, _ int) v1alpha5.NodeSelectorRequirementWithMinValues {
+				return v1alpha5.NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: r}
+			}),
+			KubeletConfiguration:         options.Kubelet,
+			ProviderRef:                  options.ProviderRef,
+			Taints:                       options.Taints,
+			StartupTaints:                options.StartupTaints,
+			Annotations:                  options.Annotations,
+			Labels:                       lo.Assign(options.Labels, map[string]string{DiscoveryLabel: "unspecified"}), // For node cleanup discovery
+			Limits:                       &v1alpha5.Limits{Resources: options.Limits},
+			TTLSecondsAfterEmpty:         options.TTLSecondsAfterEmpty,
+			TTLSecondsUntilExpired:       options.TTLSecondsUntilExpired,
+			TTLSecondsUntilExpiredJitter: options.TTLSecondsUntilExpiredJitter,
+			Weight:                       options.Weight,
+			Consolidation:                options.Consolidation,
+			Disruption:                   options.Disruption,
+			MinimumCapacity:              options.MinimumCapacity,
+			Paused:                       options.Paused,
+			Provider:                     raw,
 		},
 		Status: options.Status,
 	}
@@ -41,10 +41,17 @@ func Settings(overrides ...settings.Settings) *settings.Settings {
 	if options.TTLAfterNotRegistered == nil {
 		options.TTLAfterNotRegistered = &metav1.Duration{Duration: time.Minute * 15}
 	}
+	if options.CircuitBreakerCooldown == nil {
+		options.CircuitBreakerCooldown = &metav1.Duration{Duration: time.Minute}
+	}
 	return &settings.Settings{
 		BatchMaxDuration:      options.BatchMaxDuration,
 		BatchIdleDuration:     options.BatchIdleDuration,
 		TTLAfterNotRegistered: options.TTLAfterNotRegistered,
 		DriftEnabled:          options.DriftEnabled,
+
+		CloudProviderRetries:    options.CloudProviderRetries,
+		CircuitBreakerThreshold: options.CircuitBreakerThreshold,
+		CircuitBreakerCooldown:  options.CircuitBreakerCooldown,
 	}
 }
@@ -34,7 +34,7 @@ func New(node *v1.Node, provisioner *v1alpha5.Provisioner) *v1alpha5.Machine {
 	machine.Spec.Kubelet = provisioner.Spec.KubeletConfiguration
 	machine.Spec.Taints = provisioner.Spec.Taints
 	machine.Spec.StartupTaints = provisioner.Spec.StartupTaints
-	machine.Spec.Requirements = provisioner.Spec.Requirements
+	machine.Spec.Requirements = provisioner.Spec.NodeSelectorRequirements()
 	machine.Spec.MachineTemplateRef = provisioner.Spec.ProviderRef
 	lo.Must0(controllerutil.SetOwnerReference(provisioner, machine, scheme.Scheme))
 	return machine
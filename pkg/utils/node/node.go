@@ -17,10 +17,14 @@ package node
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
 	"github.com/aws/karpenter-core/pkg/utils/pod"
 )
 
@@ -56,3 +60,25 @@ func GetCondition(n *v1.Node, match v1.NodeConditionType) v1.NodeCondition {
 	}
 	return v1.NodeCondition{}
 }
+
+// GetExpirationTime returns the time at which the node expires. Nodes expire TTLSecondsUntilExpired after they are
+// created, plus an offset within TTLSecondsUntilExpiredJitter that is derived from the node name so that it is stable
+// across restarts. Nodes of provisioners without TTLSecondsUntilExpired expire far in the future.
+func GetExpirationTime(node *v1.Node, provisioner *v1alpha5.Provisioner) time.Time {
+	if provisioner == nil || provisioner.Spec.TTLSecondsUntilExpired == nil {
+		// If not defined, return some much larger time.
+		return time.Date(5000, 0, 0, 0, 0, 0, 0, time.UTC)
+	}
+	expirationTTL := time.Duration(ptr.Int64Value(provisioner.Spec.TTLSecondsUntilExpired)) * time.Second
+	return node.CreationTimestamp.Add(expirationTTL + expirationOffset(node.Name, ptr.Int64Value(provisioner.Spec.TTLSecondsUntilExpiredJitter)))
+}
+
+// expirationOffset deterministically maps the node name to an offset in [0, jitterSeconds)
+func expirationOffset(name string, jitterSeconds int64) time.Duration {
+	if jitterSeconds <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return time.Duration(h.Sum64()%uint64(jitterSeconds)) * time.Second
+}